@@ -0,0 +1,225 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+var (
+	ErrInvalidBatchLine = errors.New("invalid batch line, expected <url> [| <event name> [| <boards>]]")
+	ErrOutputTaken      = errors.New("output is written by another tournament of the batch, add {index} to -name")
+)
+
+type batchEntry struct {
+	index     int
+	line      int
+	baseUrl   string
+	eventName string
+	boards    string
+}
+
+type batchResult struct {
+	entry   batchEntry
+	summary extractSummary
+	err     error
+}
+
+func runBatch(args []string) {
+	fs := flag.NewFlagSet("batch", flag.ExitOnError)
+	var opts extractOptions
+	opts.registerOutputFlags(fs)
+	ef := extractorFlags{rate: 100 * time.Millisecond}
+	ef.register(fs)
+	var cf configFlags
+	cf.register(fs)
+	var dir string
+	fs.StringVar(&dir, "dir", ".", "Directory to write extracted tournaments to")
+	var name string
+	fs.StringVar(&name, "name", "{event}.{ext}", "File name template, {event} (the event name of the list if given), {ext} and {index} (position in the list) are replaced. A tournament whose file is already written by another one fails, add {index} when event names repeat")
+	var sf summaryFlags
+	sf.register(fs)
+	var concurrency int
+	fs.IntVar(&concurrency, "concurrency", 2, "Number of tournaments extracted at once")
+	fs.Usage = commandUsage(fs, "batch [options] [file]",
+		"Extract every tournament listed in file (or stdin), one per line as <url> [| <event name> [| <boards>]].\nEmpty lines and lines starting with # are skipped.")
+	_ = fs.Parse(args)
+	cf.apply(fs)
+	if err := opts.validateUpdate(); err != nil {
+		exitf(exitInvalidInput, "%v\n", err)
+		return
+	}
+
+	r := os.Stdin
+	if fs.NArg() > 0 && fs.Arg(0) != "-" {
+		f, err := os.Open(fs.Arg(0))
+		if err != nil {
+			exitf(exitInvalidInput, "Failed to open file: %v\n", err)
+			return
+		}
+		defer f.Close()
+		r = f
+	}
+	entries, err := readBatch(r)
+	if err != nil {
+		exitf(exitInvalidInput, "%v\n", err)
+		return
+	}
+	if len(entries) == 0 {
+		log.Println("Nothing to extract")
+		return
+	}
+	err = os.MkdirAll(dir, 0755)
+	if err != nil {
+		exitf(exitOutputFailed, "Failed to create output directory: %v\n", err)
+		return
+	}
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	ext := ef.extractor()
+	opts.outputs = newOutputRegistry()
+	jobs := make(chan batchEntry)
+	results := make([]batchResult, len(entries))
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for entry := range jobs {
+				entryOpts := opts
+				entryOpts.baseUrl = entry.baseUrl
+				entryOpts.eventName = entry.eventName
+				entryOpts.boards = entry.boards
+				entryOpts.output = filepath.Join(dir, strings.ReplaceAll(name, "{index}", strconv.Itoa(entry.index)))
+				entryOpts.logger = log.New(os.Stderr, fmt.Sprintf("[%d] ", entry.index), log.LstdFlags|log.Lmsgprefix)
+				summary, err := extractTournament(ext, entryOpts)
+				if err != nil {
+					entryOpts.logger.Println(err)
+				}
+				results[entry.index-1] = batchResult{entry: entry, summary: summary, err: err}
+			}
+		}()
+	}
+	for _, entry := range entries {
+		jobs <- entry
+	}
+	close(jobs)
+	wg.Wait()
+
+	if sf.format != "" {
+		reports := make([]runReport, 0, len(results))
+		for _, result := range results {
+			reports = append(reports, newRunReport(result.entry.baseUrl, result.summary, result.err))
+		}
+		err = sf.write(reports, false)
+		if err != nil {
+			log.Printf("Failed to write summary: %v\n", err)
+		}
+	} else {
+		printBatchSummary(os.Stdout, results)
+	}
+	for _, result := range results {
+		if code := result.exitCode(opts.strict); code != exitOK {
+			os.Exit(code)
+		}
+	}
+}
+
+// exitCode of a tournament, failed tournaments are reported before ones with missing boards by the caller
+// checking them in list order.
+func (r batchResult) exitCode(strict bool) int {
+	if r.err != nil {
+		return exitCodeOf(r.err)
+	}
+	return r.summary.exitCode(strict)
+}
+
+func readBatch(r io.Reader) ([]batchEntry, error) {
+	var entries []batchEntry
+	scanner := bufio.NewScanner(r)
+	var lineNumber int
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.Split(line, "|")
+		if len(parts) > 3 {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, ErrInvalidBatchLine)
+		}
+		entry := batchEntry{index: len(entries) + 1, line: lineNumber, baseUrl: strings.TrimSpace(parts[0])}
+		if _, err := url.ParseRequestURI(entry.baseUrl); err != nil {
+			return nil, fmt.Errorf("line %d: URL is invalid", lineNumber)
+		}
+		if len(parts) > 1 {
+			entry.eventName = strings.TrimSpace(parts[1])
+		}
+		if len(parts) > 2 {
+			// spaces inside terms are kept, session names may have them
+			terms := strings.Split(strings.TrimSpace(parts[2]), ",")
+			for i := range terms {
+				terms[i] = strings.TrimSpace(terms[i])
+			}
+			entry.boards = strings.Join(terms, ",")
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// outputRegistry remembers outputs of a batch, tournaments sharing an event name would otherwise overwrite each
+// other.
+type outputRegistry struct {
+	mu    sync.Mutex
+	taken map[string]bool
+}
+
+func newOutputRegistry() *outputRegistry {
+	return &outputRegistry{taken: make(map[string]bool)}
+}
+
+// claim fails with ErrOutputTaken if output was claimed already.
+func (o *outputRegistry) claim(output string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	key := filepath.Clean(output)
+	if o.taken[key] {
+		return fmt.Errorf("%s: %w", output, ErrOutputTaken)
+	}
+	o.taken[key] = true
+	return nil
+}
+
+// printBatchSummary writes a table of all tournaments.
+func printBatchSummary(w io.Writer, results []batchResult) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "#\tURL\tEVENT\tBOARDS\tFAILED\tOUTPUT\tERROR")
+	var failed, boards, boardFailures int
+	for _, result := range results {
+		errText := ""
+		if result.err != nil {
+			failed++
+			errText = result.err.Error()
+		}
+		boards += result.summary.successes
+		boardFailures += result.summary.failures
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%d\t%d\t%s\t%s\n", result.entry.index, result.entry.baseUrl, result.summary.eventName,
+			result.summary.successes, result.summary.failures, result.summary.output, errText)
+	}
+	tw.Flush()
+	fmt.Fprintf(w, "\n%d of %d tournaments extracted, %d boards, %d board failures.\n", len(results)-failed, len(results), boards, boardFailures)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_readBatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []batchEntry
+		wantErr bool
+	}{
+		{
+			name:  "url only",
+			input: "https://example.com/t1/\n",
+			want:  []batchEntry{{index: 1, line: 1, baseUrl: "https://example.com/t1/"}},
+		},
+		{
+			name:  "comments, blank lines and all fields",
+			input: "# weekend\n\nhttps://example.com/t1/ | Saturday Pairs\n https://example.com/t2/ | Sunday | 1-12, 14 \n",
+			want: []batchEntry{
+				{index: 1, line: 3, baseUrl: "https://example.com/t1/", eventName: "Saturday Pairs"},
+				{index: 2, line: 4, baseUrl: "https://example.com/t2/", eventName: "Sunday", boards: "1-12,14"},
+			},
+		},
+		{
+			name:  "session name with spaces",
+			input: "https://example.com/t1/ | Saturday | session:Saturday Morning , !3\n",
+			want:  []batchEntry{{index: 1, line: 1, baseUrl: "https://example.com/t1/", eventName: "Saturday", boards: "session:Saturday Morning,!3"}},
+		},
+		{
+			name:    "too many fields",
+			input:   "https://example.com/t1/ | a | 1-2 | b\n",
+			wantErr: true,
+		},
+		{
+			name:    "invalid url",
+			input:   "not an url\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readBatch(strings.NewReader(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("readBatch() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("readBatch() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"flag"
+	"strconv"
+	"time"
+
+	"github.com/fe-dox/tc-pbn-extractor/internal/config"
+)
+
+type configFlags struct {
+	path    string
+	profile string
+}
+
+func (cf *configFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&cf.path, "config", "", "Config file (YAML or TOML), if empty tcpbn/config.yaml is looked up in XDG config directories")
+	fs.StringVar(&cf.profile, "profile", "", "Config profile to use, if empty the profile named in the config file is used")
+}
+
+// apply sets every flag that was not given on the command line to its value from the config file, so flags
+// always win over the file.
+func (cf *configFlags) apply(fs *flag.FlagSet) {
+	path := cf.path
+	if path == "" {
+		path = config.Find()
+	}
+	if path == "" {
+		if cf.profile != "" {
+			exitf(exitInvalidInput, "Profile %q selected, but no config file was found\n", cf.profile)
+		}
+		return
+	}
+	profile, keys, err := config.Load(path, cf.profile)
+	if err != nil {
+		exitf(exitInvalidInput, "Failed to load config: %v\n", err)
+		return
+	}
+	given := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		given[f.Name] = true
+	})
+	for name, value := range profileFlagValues(profile, keys) {
+		if given[name] || fs.Lookup(name) == nil {
+			continue
+		}
+		err = fs.Set(name, value)
+		if err != nil {
+			exitf(exitInvalidInput, "Invalid value of %s in %s: %v\n", name, path, err)
+			return
+		}
+	}
+}
+
+// profileFlags maps flags to the config keys setting them.
+var profileFlags = map[string]string{
+	"agent":          "extractor.agent",
+	"timeout":        "extractor.timeout",
+	"rate":           "extractor.rate",
+	"retries":        "extractor.retries",
+	"generator":      "output.generator",
+	"format":         "output.format",
+	"dir":            "output.dir",
+	"name":           "output.name",
+	"fill-missing":   "output.fill-missing",
+	"split":          "output.split",
+	"addr":           "server.addr",
+	"cache":          "server.cache",
+	"cache-memory":   "server.cache-memory",
+	"database":       "server.database",
+	"retention":      "server.retention",
+	"redis":          "server.redis",
+	"queue":          "server.queue",
+	"workers":        "server.workers",
+	"result-ttl":     "server.result-ttl",
+	"processing-ttl": "server.processing-ttl",
+}
+
+// profileFlagValues returns flag values of the keys set in the config file, zero values included.
+func profileFlagValues(p config.Profile, keys config.Keys) map[string]string {
+	all := map[string]string{
+		"agent":          p.Extractor.UserAgent,
+		"timeout":        time.Duration(p.Extractor.Timeout).String(),
+		"rate":           time.Duration(p.Extractor.Rate).String(),
+		"retries":        strconv.Itoa(p.Extractor.Retries),
+		"generator":      p.Output.Generator,
+		"format":         p.Output.Format,
+		"dir":            p.Output.Dir,
+		"name":           p.Output.Name,
+		"fill-missing":   strconv.FormatBool(p.Output.FillMissing),
+		"split":          strconv.FormatBool(p.Output.Split),
+		"addr":           p.Server.Addr,
+		"cache":          p.Server.Cache,
+		"cache-memory":   strconv.Itoa(p.Server.CacheMemory),
+		"database":       p.Server.Database,
+		"retention":      time.Duration(p.Server.Retention).String(),
+		"redis":          p.Server.Redis,
+		"queue":          p.Server.Queue,
+		"workers":        strconv.Itoa(p.Server.Workers),
+		"result-ttl":     time.Duration(p.Server.ResultTTL).String(),
+		"processing-ttl": time.Duration(p.Server.ProcessingTTL).String(),
+	}
+	values := make(map[string]string, len(keys))
+	for name, key := range profileFlags {
+		if keys[key] {
+			values[name] = all[name]
+		}
+	}
+	return values
+}
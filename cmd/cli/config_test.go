@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const testConfig = `
+extractor:
+  retries: 3
+  rate: 1s
+output:
+  fill-missing: true
+profiles:
+  offline:
+    extractor:
+      retries: 0
+      rate: 0s
+    output:
+      fill-missing: false
+`
+
+func Test_configFlags_apply(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(testConfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name        string
+		args        []string
+		retries     int
+		rate        time.Duration
+		fillMissing bool
+	}{
+		{name: "top level", args: []string{"-config", path}, retries: 3, rate: time.Second, fillMissing: true},
+		{name: "profile setting zero values", args: []string{"-config", path, "-profile", "offline"}, retries: 0, rate: 0, fillMissing: false},
+		{name: "flags win", args: []string{"-config", path, "-profile", "offline", "-retries", "5"}, retries: 5, rate: 0, fillMissing: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			ef := extractorFlags{rate: 100 * time.Millisecond}
+			ef.register(fs)
+			var cf configFlags
+			cf.register(fs)
+			var fillMissing bool
+			fs.BoolVar(&fillMissing, "fill-missing", false, "")
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+			cf.apply(fs)
+			if ef.retries != tt.retries || ef.rate != tt.rate || fillMissing != tt.fillMissing {
+				t.Errorf("apply() retries = %d, rate = %v, fill-missing = %v, want %d, %v, %v",
+					ef.retries, ef.rate, fillMissing, tt.retries, tt.rate, tt.fillMissing)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fe-dox/tc-pbn-extractor/internal/deal"
+	"github.com/fe-dox/tc-pbn-extractor/internal/export"
+	"github.com/fe-dox/tc-pbn-extractor/internal/pbnfile"
+)
+
+func runConvert(args []string) {
+	fs := flag.NewFlagSet("convert", flag.ExitOnError)
+	var format string
+	fs.StringVar(&format, "format", string(export.FormatJSON), fmt.Sprintf("Output format, one of %v", export.Formats))
+	var output string
+	fs.StringVar(&output, "out", "", "File to write all converted boards to, if empty each input is written next to it with the format's extension")
+	var writeToStdOut bool
+	fs.BoolVar(&writeToStdOut, "stdout", false, "Write converted boards to stdout instead of file")
+	var tf transformFlags
+	tf.register(fs)
+	fs.Usage = commandUsage(fs, "convert [options] <file.pbn>...", "Convert PBN files to another format, use - to read from stdin.")
+	_ = fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return
+	}
+	outputFormat := export.Format(format)
+	if _, err := export.NewWriter(outputFormat, io.Discard); err != nil {
+		exitf(exitInvalidInput, "Invalid format %q: %v\n", format, err)
+		return
+	}
+
+	var shared export.Writer
+	var sharedFile *os.File
+	transform := tf.transform()
+	if writeToStdOut || output != "" {
+		sharedFile = os.Stdout
+		if !writeToStdOut {
+			f, err := os.Create(output)
+			if err != nil {
+				exitf(exitOutputFailed, "Failed to open file: %v\n", err)
+				return
+			}
+			sharedFile = f
+		}
+		shared, _ = export.NewWriter(outputFormat, sharedFile)
+	}
+
+	for _, input := range fs.Args() {
+		w := shared
+		var f *os.File
+		if w == nil {
+			var err error
+			name := strings.TrimSuffix(input, filepath.Ext(input)) + "." + outputFormat.Extension()
+			f, err = os.Create(name)
+			if err != nil {
+				exitf(exitOutputFailed, "Failed to open file: %v\n", err)
+				return
+			}
+			w, _ = export.NewWriter(outputFormat, f)
+			transform = tf.transform()
+		}
+		err := convertFile(input, w, transform)
+		if err != nil {
+			exitf(exitInvalidInput, "Failed to convert %s: %v\n", input, err)
+			return
+		}
+		if f != nil {
+			err = w.Flush()
+			if err == nil {
+				err = f.Close()
+			}
+			if err != nil {
+				exitf(exitOutputFailed, "Failed to write file: %v\n", err)
+				return
+			}
+		}
+	}
+	if shared != nil {
+		err := shared.Flush()
+		if err == nil {
+			err = sharedFile.Close()
+		}
+		if err != nil {
+			exitf(exitOutputFailed, "Failed to write output: %v\n", err)
+			return
+		}
+	}
+}
+
+func convertFile(input string, w export.Writer, transform deal.Transform) error {
+	r := os.Stdin
+	if input != "-" {
+		f, err := os.Open(input)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	boards, err := pbnfile.Read(r)
+	if err != nil {
+		return err
+	}
+	for _, board := range boards {
+		source := export.Source{Url: input, Board: board.Number}
+		err = w.WriteBoard(transform(board), source)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
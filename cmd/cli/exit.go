@@ -0,0 +1,69 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"os"
+
+	"github.com/fe-dox/tc-pbn-extractor/internal/app"
+)
+
+// Exit codes, so scripts can tell what went wrong without parsing logs.
+const (
+	exitOK                = 0
+	exitFailure           = 1
+	exitInvalidInput      = 2
+	exitSettingsFailed    = 3
+	exitPartialExtraction = 4
+	exitOutputFailed      = 5
+	exitBoardFailed       = 6
+	exitInvalidBoards     = 7
+)
+
+// exitError attaches an exit code to an error returned deep inside a command.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e exitError) Error() string {
+	return e.err.Error()
+}
+
+func (e exitError) Unwrap() error {
+	return e.err
+}
+
+func withExitCode(code int, err error) error {
+	if err == nil {
+		return nil
+	}
+	return exitError{code: code, err: err}
+}
+
+func exitCodeOf(err error) int {
+	if err == nil {
+		return exitOK
+	}
+	var e exitError
+	if errors.As(err, &e) {
+		return e.code
+	}
+	return exitFailure
+}
+
+// startupExitCode tells configuration errors of serve and worker from failures to reach their backends.
+func startupExitCode(err error) int {
+	for _, invalid := range []error{app.ErrUnknownCache, app.ErrUnknownQueue, app.ErrQueueSharedCache, app.ErrWorkerQueue} {
+		if errors.Is(err, invalid) {
+			return exitInvalidInput
+		}
+	}
+	return exitFailure
+}
+
+// exitf logs like log.Fatalf, but exits with code.
+func exitf(code int, format string, v ...interface{}) {
+	log.Printf(format, v...)
+	os.Exit(code)
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/fe-dox/tc-pbn-extractor/internal/app"
+)
+
+func Test_startupExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{name: "unknown queue", err: fmt.Errorf("queue: %w", app.ErrUnknownQueue), want: exitInvalidInput},
+		{name: "worker without redis queue", err: app.ErrWorkerQueue, want: exitInvalidInput},
+		{name: "unreachable redis", err: errors.New("dial tcp: connection refused"), want: exitFailure},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := startupExitCode(tt.err); got != tt.want {
+				t.Errorf("startupExitCode() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,407 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fe-dox/go-pbn"
+	"github.com/fe-dox/tc-pbn-extractor/internal/deal"
+	"github.com/fe-dox/tc-pbn-extractor/internal/export"
+	"github.com/fe-dox/tc-pbn-extractor/internal/extractor"
+	"github.com/fe-dox/tc-pbn-extractor/internal/selection"
+	"github.com/fe-dox/tc-pbn-extractor/internal/usebio"
+)
+
+type extractOptions struct {
+	baseUrl                string
+	output                 string
+	writeToStdOut          bool
+	eventName              string
+	generatorName          string
+	boards                 string
+	splitOnDiscontinuation bool
+	fillMissing            bool
+	format                 export.Format
+	transforms             transformFlags
+	usebioOutput           string
+	strict                 bool
+	update                 bool
+	logger                 *log.Logger
+	progress               bool
+	// outputs is shared by tournaments of a batch, so two of them never write the same file
+	outputs *outputRegistry
+}
+
+type extractSummary struct {
+	eventName string
+	output    string
+	successes int
+	failures  int
+	total     int
+	started   time.Time
+	duration  time.Duration
+	boards    []boardReport
+}
+
+// registerOutputFlags registers flags shared by extract and batch, which only differ in where the URL and
+// output name come from.
+func (opts *extractOptions) registerOutputFlags(fs *flag.FlagSet) {
+	fs.StringVar(&opts.generatorName, "generator", "tc-pbn-extractor", "Generator name to use in PBN")
+	fs.BoolVar(&opts.splitOnDiscontinuation, "split", false, "Split boards to different files on numeration discontinuation (untested)")
+	fs.BoolVar(&opts.fillMissing, "fill-missing", false, "Fill missing boards with empty boards")
+	fs.BoolVar(&opts.update, "update", false, "Keep boards already in the output PBN and only extract missing ones and fill-missing placeholders, TC boards of files written without -update are extracted again")
+	fs.BoolVar(&opts.strict, "strict", false, "Fail the run when any board has validation problems, including missing double dummy data")
+	fs.Func("format", fmt.Sprintf("Output format, one of %v (default %s)", export.Formats, export.FormatPBN), func(s string) error {
+		if _, err := export.NewWriter(export.Format(s), io.Discard); err != nil {
+			return err
+		}
+		opts.format = export.Format(s)
+		return nil
+	})
+	opts.format = export.FormatPBN
+	opts.transforms.register(fs)
+}
+
+func runExtract(args []string) {
+	fs := flag.NewFlagSet("extract", flag.ExitOnError)
+	var opts extractOptions
+	fs.BoolVar(&opts.writeToStdOut, "stdout", false, "Write PBN to stdout instead of file")
+	fs.StringVar(&opts.output, "out", "", "File to write PBN to, if empty will write to <event-name>.pbn. {event} and {ext} are replaced with event name (-event if given) and format extension")
+	fs.StringVar(&opts.eventName, "event", "", "Event name to use in PBN, if empty will be extracted from tournament settings")
+	fs.StringVar(&opts.boards, "boards", "", "Boards to extract, if empty will extract all boards. Comma separated <board>, <from>-<to>, all, odd, even, session:<number or name>, !<excluded>, prefix with played: to select by number as played, e.g. 1-36,!13")
+	fs.StringVar(&opts.usebioOutput, "usebio", "", "File to write results (travellers, pairs and rankings) to as USEBIO XML, if empty results are not written")
+	opts.registerOutputFlags(fs)
+	var dir string
+	fs.StringVar(&dir, "dir", "", "Directory to write output to when -out is a relative path")
+	var ef extractorFlags
+	ef.register(fs)
+	var cf configFlags
+	cf.register(fs)
+	fs.StringVar(&opts.baseUrl, "url", "", "URL to extract PBN from")
+	fs.BoolVar(&opts.progress, "progress", true, "Show a progress bar when stderr is a terminal")
+	var sf summaryFlags
+	sf.register(fs)
+
+	fs.Usage = commandUsage(fs, "extract [options] <url>", "Extract boards of a tournament to PBN or another format.")
+	_ = fs.Parse(args)
+	cf.apply(fs)
+
+	baseUrl, ok := baseUrlFromArgs(fs, opts.baseUrl)
+	if !ok {
+		return
+	}
+	opts.baseUrl = baseUrl
+	opts.logger = log.Default()
+	if dir != "" {
+		err := os.MkdirAll(dir, 0755)
+		if err != nil {
+			exitf(exitOutputFailed, "Failed to create output directory: %v\n", err)
+			return
+		}
+		if opts.output == "" {
+			opts.output = "{event}.{ext}"
+		}
+		if !filepath.IsAbs(opts.output) {
+			opts.output = filepath.Join(dir, opts.output)
+		}
+		if opts.usebioOutput != "" && !filepath.IsAbs(opts.usebioOutput) {
+			opts.usebioOutput = filepath.Join(dir, opts.usebioOutput)
+		}
+	}
+
+	opts.progress = opts.progress && isTerminal(os.Stderr)
+	if err := opts.validateUpdate(); err != nil {
+		exitf(exitInvalidInput, "%v\n", err)
+		return
+	}
+	summary, err := extractTournament(ef.extractor(), opts)
+	if reportErr := sf.write(newRunReport(opts.baseUrl, summary, err), opts.writeToStdOut); reportErr != nil {
+		log.Printf("Failed to write summary: %v\n", reportErr)
+	}
+	if err != nil {
+		exitf(exitCodeOf(err), "%v\n", err)
+		return
+	}
+	log.Printf("Extracted %d boards succesfully. Failed %d times. ¯\\_(ツ)_/¯\n", summary.successes, summary.failures)
+	if code := summary.exitCode(opts.strict); code != exitOK {
+		if summary.failures == 0 {
+			log.Println("Strict mode: some boards did not pass validation")
+		}
+		os.Exit(code)
+	}
+}
+
+// exitCode tells whether the run is a partial extraction, in strict mode validation problems count as well.
+func (s extractSummary) exitCode(strict bool) int {
+	if s.failures > 0 {
+		return exitPartialExtraction
+	}
+	if strict {
+		for _, board := range s.boards {
+			if len(board.Warnings) > 0 {
+				return exitPartialExtraction
+			}
+		}
+	}
+	return exitOK
+}
+
+func extractTournament(ext *extractor.Extractor, opts extractOptions) (summary extractSummary, err error) {
+	summary.started = time.Now()
+	defer func() {
+		summary.duration = time.Since(summary.started)
+	}()
+	settings, err := ext.ExtractSettingsFromUrl(opts.baseUrl)
+	if err != nil {
+		return summary, withExitCode(exitSettingsFailed, fmt.Errorf("failed to extract settings: %w", err))
+	}
+
+	eventName := opts.eventName
+	if eventName == "" {
+		eventName = settings.EventName
+	}
+	output := expandOutputName(opts.output, eventName, opts.format)
+	if opts.outputs != nil {
+		err = opts.outputs.claim(output)
+		if err != nil {
+			return summary, withExitCode(exitOutputFailed, err)
+		}
+	}
+	summary.eventName = eventName
+	summary.output = output
+
+	sel, err := selection.Parse(opts.boards, settings)
+	if err != nil {
+		return summary, withExitCode(exitInvalidInput, err)
+	}
+
+	var existing []existingBoard
+	if opts.update {
+		existing, err = readExistingBoards(output)
+		if err != nil {
+			return summary, withExitCode(exitInvalidInput, fmt.Errorf("failed to read %s: %w", output, err))
+		}
+		// with numbers as played every board has to be downloaded to learn its number
+		if !sel.AsPlayed {
+			extracted := extractedTcBoards(existing)
+			missing := make([]int, 0, len(sel.Boards))
+			for _, number := range sel.Boards {
+				if !extracted[number] {
+					missing = append(missing, number)
+				}
+			}
+			sel.Boards = missing
+		}
+		opts.logger.Printf("%d boards already in %s, extracting %d\n", len(existing), output, len(sel.Boards))
+		if len(sel.Boards) == 0 {
+			return summary, nil
+		}
+	}
+	summary.total = len(sel.Boards)
+	logger := opts.logger
+	var bar *progressBar
+	if opts.progress {
+		bar = newProgressBar(os.Stderr, summary.total)
+		defer bar.finish()
+		logger = log.New(bar, logger.Prefix(), logger.Flags())
+	}
+
+	type extractionResult struct {
+		Number   int
+		Board    []pbn.Board
+		Protocol extractor.RawProtocol
+		Info     extractor.FetchInfo
+		Duration time.Duration
+		Err      error
+	}
+	ch := make(chan extractionResult, 1)
+	// done stops the producer when we bail out early, so remaining boards are not downloaded
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		defer close(ch)
+		for _, i := range sel.Boards {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			start := time.Now()
+			protocol, info, err := ext.ExtractProtocolWithInfo(opts.baseUrl, i)
+			var board []pbn.Board
+			if err == nil {
+				board, err = extractor.BoardsFromProtocol(protocol)
+			}
+			if err != nil {
+				board = []pbn.Board{{Number: i}}
+			} else {
+				board = sel.Filter(board)
+			}
+			result := extractionResult{
+				Number:   i,
+				Err:      err,
+				Board:    board,
+				Protocol: protocol,
+				Info:     info,
+				Duration: time.Since(start),
+			}
+			select {
+			case ch <- result:
+			case <-done:
+				return
+			}
+		}
+	}()
+	var prevBoardNumber int
+	var currentSplit int
+	var w *os.File
+	// an update goes to a temporary file first, so the previous boards survive a failed run
+	target := output
+	if opts.update {
+		target = output + ".tmp"
+	}
+	if opts.writeToStdOut {
+		w = os.Stdout
+	} else {
+		w, err = os.Create(target)
+	}
+	if err != nil {
+		return summary, withExitCode(exitOutputFailed, fmt.Errorf("failed to open file: %w", err))
+	}
+	renamed := false
+	// a failed update removes its temporary file, the previous output is left as it was
+	if opts.update {
+		defer func() {
+			if !renamed {
+				_ = w.Close()
+				_ = os.Remove(target)
+			}
+		}()
+	}
+	ew, _ := export.NewWriter(opts.format, w)
+	if opts.update {
+		ew = newUpdateWriter(w, existing)
+	}
+	if opts.splitOnDiscontinuation && !strings.Contains(output, "%d") {
+		extension := "." + opts.format.Extension()
+		output = strings.TrimSuffix(output, extension)
+		output = output + "-%d" + extension
+	}
+	transform := opts.transforms.transform()
+	protocols := make(map[int]extractor.RawProtocol)
+	for boardResults := range ch {
+		if len(boardResults.Protocol.ScoringGroups) > 0 {
+			protocols[boardResults.Number] = boardResults.Protocol
+		}
+		report := boardReport{
+			Board:      boardResults.Number,
+			Status:     boardExtracted,
+			DurationMs: boardResults.Duration.Milliseconds(),
+			HttpStatus: boardResults.Info.StatusCode,
+			Retries:    boardResults.Info.Retries,
+		}
+		if boardResults.Err == nil && len(boardResults.Board) == 0 {
+			report.Status = boardSkipped
+		}
+		if boardResults.Err != nil {
+			logger.Printf("Failed to extract Board %d: %v\n", boardResults.Board[0].Number, boardResults.Err)
+			summary.failures++
+			report.Status = boardFailed
+			report.Error = boardResults.Err.Error()
+		}
+		if boardResults.Err == nil || opts.fillMissing {
+			for _, board := range boardResults.Board {
+				if opts.splitOnDiscontinuation && !opts.writeToStdOut {
+					if prevBoardNumber > board.Number {
+						err := ew.Flush()
+						if err != nil {
+							return summary, withExitCode(exitOutputFailed, fmt.Errorf("failed to write file: %w", err))
+						}
+						err = w.Close()
+						if err != nil {
+							return summary, withExitCode(exitOutputFailed, fmt.Errorf("failed to close file: %w", err))
+						}
+						w, err = os.Create(fmt.Sprintf(output, currentSplit))
+						if err != nil {
+							return summary, withExitCode(exitOutputFailed, fmt.Errorf("failed to open file: %w", err))
+						}
+						ew, _ = export.NewWriter(opts.format, w)
+						currentSplit++
+					}
+					prevBoardNumber = board.Number
+				}
+				if boardResults.Err == nil {
+					report.Played = append(report.Played, board.Number)
+					for _, problem := range deal.Validate(board) {
+						report.Warnings = append(report.Warnings, fmt.Sprintf("board %d: %v", board.Number, problem))
+					}
+				}
+				board.EventName = eventName
+				board.Generator = opts.generatorName
+				board = transform(board)
+				err = ew.WriteBoard(board, export.Source{Url: opts.baseUrl, Board: boardResults.Number})
+				if err != nil {
+					logger.Printf("Failed to serialize Board %d (number as played): %v\n", board.Number, err)
+					summary.failures += 1
+					report.Status = boardFailed
+					report.Error = err.Error()
+					continue
+				}
+				summary.successes += 1
+			}
+		}
+		summary.boards = append(summary.boards, report)
+		if bar != nil {
+			bar.add(report.Status == boardFailed)
+		}
+	}
+	err = ew.Flush()
+	if err == nil && !opts.writeToStdOut {
+		err = w.Close()
+	}
+	if err == nil && opts.update {
+		err = os.Rename(target, output)
+		renamed = err == nil
+	}
+	if err != nil {
+		return summary, withExitCode(exitOutputFailed, fmt.Errorf("failed to write output: %w", err))
+	}
+	if opts.usebioOutput != "" {
+		err = writeUsebio(expandOutputName(opts.usebioOutput, eventName, "xml"), eventName, opts.generatorName, protocols)
+		if err != nil {
+			return summary, withExitCode(exitOutputFailed, fmt.Errorf("failed to write USEBIO results: %w", err))
+		}
+	}
+	return summary, nil
+}
+
+// expandOutputName fills {event} and {ext} in name, an empty name means {event}.{ext}.
+func expandOutputName(name string, eventName string, format export.Format) string {
+	if name == "" {
+		name = "{event}.{ext}"
+	}
+	return strings.NewReplacer(
+		"{event}", export.SafeFileName(eventName),
+		"{ext}", format.Extension(),
+	).Replace(name)
+}
+
+func writeUsebio(output string, eventName string, generatorName string, protocols map[int]extractor.RawProtocol) error {
+	f, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	err = usebio.Write(f, usebio.Build(eventName, generatorName, protocols))
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
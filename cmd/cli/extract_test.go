@@ -0,0 +1,105 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fe-dox/tc-pbn-extractor/internal/export"
+	"github.com/fe-dox/tc-pbn-extractor/internal/extractor"
+)
+
+func Test_extractTournament_stopsDownloadsOnError(t *testing.T) {
+	var mu sync.Mutex
+	var protocolRequests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/settings.json" {
+			_, _ = w.Write([]byte(`{"BoardsNumbers": [1, 2, 3, 4, 5, 6, 7, 8], "FullName": "Test Pairs"}`))
+			return
+		}
+		mu.Lock()
+		protocolRequests++
+		mu.Unlock()
+		var number int
+		_, _ = fmt.Sscanf(r.URL.Path, "/p%d.json", &number)
+		_, _ = w.Write([]byte(testProtocol(number, number)))
+	}))
+	defer server.Close()
+	opts := extractOptions{
+		baseUrl: server.URL + "/",
+		// the output can't be created, so the run fails before reading any board
+		output: filepath.Join(t.TempDir(), "missing", "event.pbn"),
+		format: export.FormatPBN,
+		logger: log.New(io.Discard, "", 0),
+	}
+
+	_, err := extractTournament(extractor.NewExtractor("test", time.Second), opts)
+	if exitCodeOf(err) != exitOutputFailed {
+		t.Fatalf("extractTournament() error = %v, want an output failure", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	if protocolRequests > 2 {
+		t.Errorf("%d boards downloaded after the run failed, want downloads stopped", protocolRequests)
+	}
+}
+
+func Test_extractTournament_outputTaken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"BoardsNumbers": [1], "FullName": "Test Pairs"}`))
+	}))
+	defer server.Close()
+	opts := extractOptions{
+		baseUrl: server.URL + "/",
+		output:  filepath.Join(t.TempDir(), "{event}.{ext}"),
+		format:  export.FormatPBN,
+		logger:  log.New(io.Discard, "", 0),
+		outputs: newOutputRegistry(),
+	}
+	_ = opts.outputs.claim(expandOutputName(opts.output, "Test Pairs", export.FormatPBN))
+
+	_, err := extractTournament(extractor.NewExtractor("test", time.Second), opts)
+	if !errors.Is(err, ErrOutputTaken) {
+		t.Errorf("extractTournament() of a taken output error = %v, want %v", err, ErrOutputTaken)
+	}
+}
+
+func Test_extractTournament_outputOfRenamedEvent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/settings.json":
+			_, _ = w.Write([]byte(`{"BoardsNumbers": [1], "FullName": "Test Pairs"}`))
+		case "/p1.json":
+			_, _ = w.Write([]byte(testProtocol(1, 1)))
+		}
+	}))
+	defer server.Close()
+	dir := t.TempDir()
+	outputs := newOutputRegistry()
+	// two list entries renaming tournaments that share a TC event name
+	for _, eventName := range []string{"Saturday", "Sunday"} {
+		opts := extractOptions{
+			baseUrl:   server.URL + "/",
+			eventName: eventName,
+			output:    filepath.Join(dir, "{event}.{ext}"),
+			format:    export.FormatPBN,
+			logger:    log.New(io.Discard, "", 0),
+			outputs:   outputs,
+		}
+		summary, err := extractTournament(extractor.NewExtractor("test", time.Second), opts)
+		if err != nil {
+			t.Fatalf("extractTournament() of %s error = %v", eventName, err)
+		}
+		if want := filepath.Join(dir, eventName+".pbn"); summary.output != want {
+			t.Errorf("extractTournament() of %s wrote %s, want %s", eventName, summary.output, want)
+		}
+	}
+}
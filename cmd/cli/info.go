@@ -0,0 +1,148 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/fe-dox/tc-pbn-extractor/internal/extractor"
+)
+
+type sessionInfo struct {
+	Number int    `json:"number"`
+	Name   string `json:"name,omitempty"`
+	Boards []int  `json:"boards"`
+}
+
+type tournamentInfo struct {
+	Url          string                        `json:"url"`
+	EventName    string                        `json:"event"`
+	Boards       []int                         `json:"boards"`
+	Sessions     []sessionInfo                 `json:"sessions"`
+	Availability []extractor.BoardAvailability `json:"availability,omitempty"`
+}
+
+func runInfo(args []string) {
+	fs := flag.NewFlagSet("info", flag.ExitOnError)
+	var ef extractorFlags
+	ef.register(fs)
+	var cf configFlags
+	cf.register(fs)
+	var baseUrl string
+	fs.StringVar(&baseUrl, "url", "", "URL of the tournament")
+	var probe bool
+	fs.BoolVar(&probe, "probe", true, "Fetch every board to report what is available")
+	var asJson bool
+	fs.BoolVar(&asJson, "json", false, "Print the report as JSON instead of a table")
+	var delay time.Duration
+	fs.DurationVar(&delay, "delay", 100*time.Millisecond, "Delay between probing consecutive boards")
+	fs.Usage = commandUsage(fs, "info [options] <url>", "Print what the tournament settings say about the event and which boards can be extracted.")
+	_ = fs.Parse(args)
+	cf.apply(fs)
+
+	baseUrl, ok := baseUrlFromArgs(fs, baseUrl)
+	if !ok {
+		return
+	}
+	ext := ef.extractor()
+	settings, err := ext.ExtractSettingsFromUrl(baseUrl)
+	if err != nil {
+		exitf(exitSettingsFailed, "Failed to extract settings: %v\n", err)
+		return
+	}
+	info := tournamentInfo{
+		Url:       baseUrl,
+		EventName: settings.EventName,
+		Boards:    settings.BoardsNumbers,
+		Sessions:  make([]sessionInfo, 0, len(settings.Sessions)),
+	}
+	for _, session := range settings.Sessions {
+		info.Sessions = append(info.Sessions, sessionInfo{Number: session.Number, Name: session.Name, Boards: session.BoardsNumbers})
+	}
+	if probe {
+		for i, number := range settings.BoardsNumbers {
+			if i > 0 {
+				time.Sleep(delay)
+			}
+			info.Availability = append(info.Availability, ext.ProbeBoard(baseUrl, number))
+		}
+	}
+
+	if asJson {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err = enc.Encode(info); err != nil {
+			exitf(exitOutputFailed, "Failed to write report: %v\n", err)
+		}
+		return
+	}
+	printInfo(os.Stdout, info, probe)
+}
+
+func printInfo(w io.Writer, info tournamentInfo, probe bool) {
+	fmt.Fprintf(w, "Event:    %s\n", info.EventName)
+	fmt.Fprintf(w, "Boards:   %s (%d boards)\n", formatBoardList(info.Boards), len(info.Boards))
+	for _, session := range info.Sessions {
+		name := ""
+		if session.Name != "" {
+			name = " " + session.Name
+		}
+		fmt.Fprintf(w, "Session %d%s: %s\n", session.Number, name, formatBoardList(session.Boards))
+	}
+	if !probe {
+		return
+	}
+
+	fmt.Fprintln(w)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "BOARD\tSTATUS\tGROUPS\tHANDS\tDD\tMINIMAX\tERROR")
+	var available, withDoubleDummy, withMiniMax, failed int
+	for _, a := range info.Availability {
+		status := "-"
+		if a.StatusCode != 0 {
+			status = strconv.Itoa(a.StatusCode)
+		}
+		if a.Error != "" {
+			failed++
+			fmt.Fprintf(tw, "%d\t%s\t-\t-\t-\t-\t%s\n", a.Number, status, a.Error)
+			continue
+		}
+		if a.Available() {
+			available++
+		}
+		if a.DoubleDummy > 0 {
+			withDoubleDummy++
+		}
+		if a.MiniMax > 0 {
+			withMiniMax++
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%d\t%d\t%d\t%d\t\n", a.Number, status, a.ScoringGroups, a.HandRecords, a.DoubleDummy, a.MiniMax)
+	}
+	tw.Flush()
+	fmt.Fprintf(w, "\n%d of %d boards have hand records, %d have double dummy tricks, %d have minimax, %d could not be fetched.\n",
+		available, len(info.Availability), withDoubleDummy, withMiniMax, failed)
+}
+
+// formatBoardList compresses consecutive board numbers into ranges, e.g. 1-4,7,9-12.
+func formatBoardList(boards []int) string {
+	var parts []string
+	for i := 0; i < len(boards); {
+		j := i
+		for j+1 < len(boards) && boards[j+1] == boards[j]+1 {
+			j++
+		}
+		if i == j {
+			parts = append(parts, strconv.Itoa(boards[i]))
+		} else {
+			parts = append(parts, fmt.Sprintf("%d-%d", boards[i], boards[j]))
+		}
+		i = j + 1
+	}
+	return strings.Join(parts, ",")
+}
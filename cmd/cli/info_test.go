@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/fe-dox/tc-pbn-extractor/internal/extractor"
+)
+
+func Test_printInfo(t *testing.T) {
+	info := tournamentInfo{
+		EventName: "Test Pairs",
+		Boards:    []int{1, 2, 3, 4},
+		Sessions: []sessionInfo{
+			{Number: 1, Name: "Round 1", Boards: []int{1, 2}},
+			{Number: 2, Boards: []int{3, 4}},
+		},
+		Availability: []extractor.BoardAvailability{
+			{Number: 1, StatusCode: 200, ScoringGroups: 2, HandRecords: 2, DoubleDummy: 2, MiniMax: 2},
+			{Number: 2, StatusCode: 200, ScoringGroups: 2, HandRecords: 1},
+			{Number: 3, StatusCode: 200, ScoringGroups: 1},
+			{Number: 4, StatusCode: 404, Error: "unexpected status code: 404"},
+		},
+	}
+	const header = `Event:    Test Pairs
+Boards:   1-4 (4 boards)
+Session 1 Round 1: 1-2
+Session 2: 3-4
+`
+	tests := []struct {
+		name  string
+		probe bool
+		want  string
+	}{
+		{name: "settings only", probe: false, want: header},
+		{
+			name:  "probed",
+			probe: true,
+			// successful rows end in an empty error cell, padded like the rest of the column
+			want: header + "\n" +
+				"BOARD  STATUS  GROUPS  HANDS  DD  MINIMAX  ERROR\n" +
+				"1      200     2       2      2   2        \n" +
+				"2      200     2       1      0   0        \n" +
+				"3      200     1       0      0   0        \n" +
+				"4      404     -       -      -   -        unexpected status code: 404\n" +
+				"\n2 of 4 boards have hand records, 1 have double dummy tricks, 1 have minimax, 1 could not be fetched.\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b bytes.Buffer
+			printInfo(&b, info, tt.probe)
+			if got := b.String(); got != tt.want {
+				t.Errorf("printInfo() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+)
+
+func runList(args []string) {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	var ef extractorFlags
+	ef.register(fs)
+	var cf configFlags
+	cf.register(fs)
+	var pageUrl string
+	fs.StringVar(&pageUrl, "url", "", "URL of a page linking to tournaments, e.g. a club's results page")
+	fs.Usage = commandUsage(fs, "list [options] <url>", "List TC tournaments found at the page and the pages it links to.")
+	_ = fs.Parse(args)
+	cf.apply(fs)
+
+	pageUrl, ok := baseUrlFromArgs(fs, pageUrl)
+	if !ok {
+		return
+	}
+	tournaments, err := ef.extractor().DiscoverTournaments(pageUrl)
+	if err != nil {
+		exitf(exitSettingsFailed, "Failed to read %s: %v\n", pageUrl, err)
+		return
+	}
+	if len(tournaments) == 0 {
+		log.Printf("No tournaments found at %s\n", pageUrl)
+		return
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "URL\tEVENT\tBOARDS")
+	for _, t := range tournaments {
+		fmt.Fprintf(tw, "%s\t%s\t%d-%d\n", t.Url, t.Settings.EventName, t.Settings.StartBoardNumber, t.Settings.EndBoardNumber)
+	}
+	tw.Flush()
+}
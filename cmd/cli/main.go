@@ -1,224 +1,124 @@
 package main
 
 import (
-	"errors"
 	"flag"
 	"fmt"
-	"log"
 	"net/url"
 	"os"
-	"strconv"
 	"strings"
 	"time"
 
-	"github.com/fe-dox/go-pbn"
 	"github.com/fe-dox/tc-pbn-extractor/internal/extractor"
 )
 
-func main() {
-	var writeToStdOut bool
-	flag.BoolVar(&writeToStdOut, "stdout", false, "Write PBN to stdout instead of file")
-	var output string
-	flag.StringVar(&output, "out", "", "File to write PBN to, if empty will write to <event-name>.pbn")
-	var eventName string
-	flag.StringVar(&eventName, "event", "", "Event name to use in PBN, if empty will be extracted from tournament settings")
-	var generatorName string
-	flag.StringVar(&generatorName, "generator", "tc-pbn-extractor", "Generator name to use in PBN")
-	var boardsToExtract string
-	flag.StringVar(&boardsToExtract, "boards", "", "Boards to extract, if empty will extract all boards. Valid notation <from>-<to>,<single>,<from>-<to>")
-	var splitOnDiscontinuation bool
-	flag.BoolVar(&splitOnDiscontinuation, "split", false, "Split boards to different files on numeration discontinuation (untested)")
-	var userAgent string
-	flag.StringVar(&userAgent, "agent", "tc-pbn-extractor", "User-Agent header to use for requests")
-	var timeout time.Duration
-	flag.DurationVar(&timeout, "timeout", 1*time.Second, "Timeout for HTTP requests")
-	var baseUrl string
-	flag.StringVar(&baseUrl, "url", "", "URL to extract PBN from")
-	var fillMissing bool
-	flag.BoolVar(&fillMissing, "fill-missing", false, "Fill missing boards with empty boards")
-
-	flag.Usage = func() {
-		fmt.Fprintf(flag.CommandLine.Output(), "Usage of tc-pbn-extractor:\n")
-		fmt.Fprintf(flag.CommandLine.Output(), "\ttcpbn.exe <url>\n")
-		fmt.Fprintf(flag.CommandLine.Output(), "\ttcpbn.exe [options]\n\n")
-		flag.PrintDefaults()
-	}
+type command struct {
+	name        string
+	description string
+	run         func(args []string)
+}
 
-	flag.Parse()
+var commands = []command{
+	{name: "extract", description: "Extract boards of a tournament (default when given just an URL)", run: runExtract},
+	{name: "batch", description: "Extract a list of tournaments into a directory", run: runBatch},
+	{name: "watch", description: "Follow a tournament in progress and write boards as they are published", run: runWatch},
+	{name: "info", description: "Print tournament settings", run: runInfo},
+	{name: "list", description: "Discover tournaments linked from a page", run: runList},
+	{name: "convert", description: "Convert PBN files to other formats", run: runConvert},
+	{name: "validate", description: "Check PBN files for invalid deals", run: runValidate},
+	{name: "render", description: "Render a board diagram to SVG or PNG", run: runRender},
+	{name: "serve", description: "Start the HTTP API", run: runServe},
+	{name: "worker", description: "Extract jobs queued by the HTTP API in Redis", run: runWorker},
+}
 
-	if baseUrl == "" {
-		baseUrl = flag.Arg(0)
-		if baseUrl == "" {
-			flag.Usage()
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		return
+	}
+	name := os.Args[1]
+	for _, c := range commands {
+		if c.name == name {
+			c.run(os.Args[2:])
 			return
 		}
 	}
-	_, err := url.ParseRequestURI(baseUrl)
-	if err != nil {
-		log.Fatal("URL is invalid")
-		return
+	switch name {
+	case "help", "-h", "-help", "--help":
+		usage()
+	default:
+		// tcpbn <url> and tcpbn [extract options] <url> predate subcommands and keep working as extract
+		if strings.HasPrefix(name, "-") || strings.Contains(name, "://") {
+			runExtract(os.Args[1:])
+			return
+		}
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n", name)
+		usage()
+		os.Exit(exitInvalidInput)
 	}
+}
 
-	ext := extractor.NewExtractor(userAgent, timeout)
-	settings, err := ext.ExtractSettingsFromUrl(baseUrl)
-	if err != nil {
-		log.Fatalf("Failed to extract settings: %v\n", err)
-		return
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage of tc-pbn-extractor:\n")
+	fmt.Fprintf(os.Stderr, "\ttcpbn.exe <url>\n")
+	fmt.Fprintf(os.Stderr, "\ttcpbn.exe <command> [options]\n\n")
+	fmt.Fprintf(os.Stderr, "Commands:\n")
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "\t%-10s%s\n", c.name, c.description)
 	}
+	fmt.Fprintf(os.Stderr, "\nRun tcpbn.exe <command> -h for options of a command.\n")
+	fmt.Fprintf(os.Stderr, "\nExit codes:\n")
+	fmt.Fprintf(os.Stderr, "\t%d\tsuccess\n", exitOK)
+	fmt.Fprintf(os.Stderr, "\t%d\tother failure\n", exitFailure)
+	fmt.Fprintf(os.Stderr, "\t%d\tinvalid input (flags, URL, board selection, config)\n", exitInvalidInput)
+	fmt.Fprintf(os.Stderr, "\t%d\ttournament settings could not be read\n", exitSettingsFailed)
+	fmt.Fprintf(os.Stderr, "\t%d\tpartial extraction, some boards are missing or, with -strict, invalid\n", exitPartialExtraction)
+	fmt.Fprintf(os.Stderr, "\t%d\toutput could not be written\n", exitOutputFailed)
+	fmt.Fprintf(os.Stderr, "\t%d\tthe board could not be downloaded (render)\n", exitBoardFailed)
+	fmt.Fprintf(os.Stderr, "\t%d\tboards have validation errors (validate)\n", exitInvalidBoards)
+}
 
-	if output == "" {
-		output = fmt.Sprintf("%s.pbn", settings.EventName)
+func commandUsage(fs *flag.FlagSet, synopsis string, description string) func() {
+	return func() {
+		fmt.Fprintf(fs.Output(), "Usage of tc-pbn-extractor %s:\n", fs.Name())
+		fmt.Fprintf(fs.Output(), "\ttcpbn.exe %s\n\n", synopsis)
+		fmt.Fprintf(fs.Output(), "%s\n\n", description)
+		fs.PrintDefaults()
 	}
+}
 
-	if eventName == "" {
-		eventName = settings.EventName
-	}
+type extractorFlags struct {
+	userAgent string
+	timeout   time.Duration
+	rate      time.Duration
+	retries   int
+}
 
-	boardRanges, err := getBoardsToExtract(boardsToExtract, settings.StartBoardNumber, settings.EndBoardNumber)
-	if err != nil {
-		log.Fatal(err)
-	}
+// register registers extractor flags, a rate set beforehand becomes the default of -rate.
+func (ef *extractorFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&ef.userAgent, "agent", "tc-pbn-extractor", "User-Agent header to use for requests")
+	fs.DurationVar(&ef.timeout, "timeout", 1*time.Second, "Timeout for HTTP requests")
+	fs.DurationVar(&ef.rate, "rate", ef.rate, "Minimum delay between any two requests to TC, 0 means no limit")
+	fs.IntVar(&ef.retries, "retries", 2, "How many times to repeat requests failing on the network or with a server error")
+}
 
-	type extractionResult struct {
-		Board []pbn.Board
-		Err   error
-	}
-	ch := make(chan extractionResult, 1)
+func (ef *extractorFlags) extractor() *extractor.Extractor {
+	return extractor.NewExtractor(ef.userAgent, ef.timeout).WithRateLimit(ef.rate).WithRetries(ef.retries)
+}
 
-	var successes int
-	var failures int
-	go func() {
-		for _, boardRange := range boardRanges {
-			for i := boardRange[0]; i <= boardRange[1]; i++ {
-				board, err := ext.ExtractOneFromUrl(baseUrl, i)
-				if err != nil {
-					board = []pbn.Board{{Number: i}}
-				}
-				ch <- extractionResult{
-					Err:   err,
-					Board: board,
-				}
-			}
+// baseUrlFromArgs falls back to the first positional argument when -url was not given. It prints usage and
+// returns false if there is no URL at all.
+func baseUrlFromArgs(fs *flag.FlagSet, baseUrl string) (string, bool) {
+	if baseUrl == "" {
+		baseUrl = fs.Arg(0)
+		if baseUrl == "" {
+			fs.Usage()
+			return "", false
 		}
-		close(ch)
-	}()
-	var prevBoardNumber int
-	var currentSplit int
-	var w *os.File
-	if writeToStdOut {
-		w = os.Stdout
-	} else {
-		w, err = os.OpenFile(output, os.O_CREATE|os.O_WRONLY, 0644)
 	}
+	_, err := url.ParseRequestURI(baseUrl)
 	if err != nil {
-		log.Fatalf("Failed to open file: %v\n", err)
-		return
-	}
-	if splitOnDiscontinuation && !strings.Contains(output, "%d") {
-		output = strings.TrimSuffix(output, ".pbn")
-		output = output + "-%d.pbn"
-	}
-	for boardResults := range ch {
-		if boardResults.Err != nil {
-			log.Printf("Failed to extract Board %d: %v\n", boardResults.Board[0].Number, boardResults.Err)
-			failures++
-			if !fillMissing {
-				continue
-			}
-		}
-		for _, board := range boardResults.Board {
-			if splitOnDiscontinuation && !writeToStdOut {
-				if prevBoardNumber > board.Number {
-					err := w.Close()
-					if err != nil {
-						log.Fatalf("Failed to close file: %v\n", err)
-						return
-					}
-					w, err = os.OpenFile(fmt.Sprintf(output, currentSplit), os.O_CREATE|os.O_WRONLY, 0644)
-					if err != nil {
-						log.Fatalf("Failed to open file: %v\n", err)
-						return
-					}
-					currentSplit++
-				}
-				prevBoardNumber = board.Number
-			}
-			board.EventName = eventName
-			board.Generator = generatorName
-			err = board.Serialize(w, true)
-			if err != nil {
-				log.Printf("Failed to serialize Board %d (number as played): %v\n", board.Number, err)
-				failures += 1
-				continue
-			}
-			successes += 1
-		}
-	}
-	w.Close()
-	log.Printf("Extracted %d boards succesfully. Failed %d times. ¯\\_(ツ)_/¯\n", successes, failures)
-
-}
-
-var ErrInvalidBoardsRange = errors.New("invalid boards range")
-var ErrSelectedBoardsDoNotExistInTournament = errors.New("selected boards do not exist in tournament")
-var ErrBoardsNotInOrder = errors.New("selected boards are not in order")
-
-func getBoardsToExtract(str string, start int, end int) ([][2]int, error) {
-	if str == "" {
-		return [][2]int{{start, end}}, nil
-	}
-	boards := make([][2]int, 0, 1)
-	ranges := strings.Split(str, ",")
-	var currentHighest int
-	if len(ranges) == 0 {
-		return nil, ErrInvalidBoardsRange
-	}
-	for _, r := range ranges {
-		if strings.Contains(r, "-") {
-			parts := strings.Split(r, "-")
-			if len(parts) != 2 {
-				return nil, ErrInvalidBoardsRange
-			}
-			from, err := strconv.Atoi(parts[0])
-			if err != nil {
-				return nil, ErrInvalidBoardsRange
-			}
-			if from <= currentHighest {
-				return nil, ErrBoardsNotInOrder
-			}
-			currentHighest = from
-			if from < start {
-				return nil, ErrSelectedBoardsDoNotExistInTournament
-			}
-			to, err := strconv.Atoi(parts[1])
-			if err != nil {
-				return nil, ErrInvalidBoardsRange
-			}
-			if to <= currentHighest {
-				return nil, ErrBoardsNotInOrder
-			}
-			currentHighest = to
-			if to > end {
-				return nil, ErrSelectedBoardsDoNotExistInTournament
-			}
-			if from > to {
-				return nil, ErrBoardsNotInOrder
-			}
-			boards = append(boards, [2]int{from, to})
-		} else {
-			board, err := strconv.Atoi(r)
-			if err != nil {
-				return nil, ErrInvalidBoardsRange
-			}
-			if board <= currentHighest {
-				return nil, ErrBoardsNotInOrder
-			}
-			if board < start || board > end {
-				return nil, ErrSelectedBoardsDoNotExistInTournament
-			}
-			currentHighest = board
-			boards = append(boards, [2]int{board, board})
-		}
+		exitf(exitInvalidInput, "URL is invalid\n")
+		return "", false
 	}
-	return boards, nil
+	return baseUrl, true
 }
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrUnknownSummaryFormat = errors.New("unknown summary format, only json is supported")
+
+const (
+	boardExtracted = "extracted"
+	boardFailed    = "failed"
+	boardSkipped   = "skipped"
+)
+
+type boardReport struct {
+	Board      int      `json:"board"`
+	Played     []int    `json:"played,omitempty"`
+	Status     string   `json:"status"`
+	DurationMs int64    `json:"durationMs"`
+	HttpStatus int      `json:"httpStatus,omitempty"`
+	Retries    int      `json:"retries"`
+	Warnings   []string `json:"warnings,omitempty"`
+	Error      string   `json:"error,omitempty"`
+}
+
+// runReport describes a run of extract. Missing lists boards that failed, skipped boards were left out by the board
+// selection on purpose and are only counted.
+type runReport struct {
+	Url        string        `json:"url"`
+	Event      string        `json:"event,omitempty"`
+	Output     string        `json:"output,omitempty"`
+	Started    time.Time     `json:"started"`
+	DurationMs int64         `json:"durationMs"`
+	Total      int           `json:"total"`
+	Extracted  int           `json:"extracted"`
+	Failed     int           `json:"failed"`
+	Skipped    int           `json:"skipped"`
+	Missing    []int         `json:"missing"`
+	Boards     []boardReport `json:"boards"`
+	Error      string        `json:"error,omitempty"`
+}
+
+func newRunReport(baseUrl string, summary extractSummary, err error) runReport {
+	report := runReport{
+		Url:        baseUrl,
+		Event:      summary.eventName,
+		Output:     summary.output,
+		Started:    summary.started,
+		DurationMs: summary.duration.Milliseconds(),
+		Total:      summary.total,
+		Extracted:  summary.successes,
+		Failed:     summary.failures,
+		Missing:    make([]int, 0),
+		Boards:     summary.boards,
+	}
+	if report.Boards == nil {
+		report.Boards = make([]boardReport, 0)
+	}
+	for _, board := range summary.boards {
+		switch board.Status {
+		case boardFailed:
+			report.Missing = append(report.Missing, board.Board)
+		case boardSkipped:
+			report.Skipped++
+		}
+	}
+	if err != nil {
+		report.Error = err.Error()
+	}
+	return report
+}
+
+func writeJson(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+const progressBarWidth = 30
+
+// progressBar draws a single, constantly redrawn status line. It is also an io.Writer for log output, which it
+// prints above the bar.
+type progressBar struct {
+	mu      sync.Mutex
+	w       io.Writer
+	total   int
+	done    int
+	failed  int
+	started time.Time
+}
+
+func newProgressBar(w io.Writer, total int) *progressBar {
+	p := &progressBar{w: w, total: total, started: time.Now()}
+	p.draw()
+	return p
+}
+
+func (p *progressBar) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.clear()
+	n, err := p.w.Write(b)
+	p.draw()
+	return n, err
+}
+
+func (p *progressBar) add(failed bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.done++
+	if failed {
+		p.failed++
+	}
+	p.draw()
+}
+
+func (p *progressBar) finish() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.clear()
+}
+
+func (p *progressBar) clear() {
+	fmt.Fprint(p.w, "\r\033[K")
+}
+
+func (p *progressBar) draw() {
+	filled := progressBarWidth
+	if p.total > 0 {
+		filled = p.done * progressBarWidth / p.total
+	}
+	line := fmt.Sprintf("\r[%s%s] %d/%d boards", strings.Repeat("#", filled), strings.Repeat(".", progressBarWidth-filled), p.done, p.total)
+	if p.failed > 0 {
+		line += fmt.Sprintf(", %d failed", p.failed)
+	}
+	if elapsed := time.Since(p.started).Seconds(); p.done > 0 && elapsed > 0 {
+		rate := float64(p.done) / elapsed
+		eta := time.Duration(float64(p.total-p.done) / rate * float64(time.Second)).Round(time.Second)
+		line += fmt.Sprintf(", %.1f boards/s, ETA %s", rate, eta)
+	}
+	fmt.Fprint(p.w, line)
+}
+
+type summaryFlags struct {
+	format string
+	output string
+}
+
+func (sf *summaryFlags) register(fs *flag.FlagSet) {
+	fs.Func("summary", "Print a machine readable report of the run with the status of every board, only json is supported", func(s string) error {
+		if s != "json" {
+			return ErrUnknownSummaryFormat
+		}
+		sf.format = s
+		return nil
+	})
+	fs.StringVar(&sf.output, "summary-out", "", "File to write the -summary report to, if empty it goes to stdout, or to stderr when boards are written to stdout")
+}
+
+// write writes report if a summary was requested.
+func (sf *summaryFlags) write(report interface{}, boardsOnStdout bool) error {
+	if sf.format == "" {
+		return nil
+	}
+	if sf.output == "" {
+		w := os.Stdout
+		if boardsOnStdout {
+			w = os.Stderr
+		}
+		return writeJson(w, report)
+	}
+	f, err := os.Create(sf.output)
+	if err != nil {
+		return err
+	}
+	err = writeJson(f, report)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_newRunReport(t *testing.T) {
+	tests := []struct {
+		name    string
+		summary extractSummary
+		err     error
+		want    runReport
+	}{
+		{
+			name: "no boards",
+			err:  errors.New("settings.json does not exist"),
+			want: runReport{Url: "https://example.com/t1/", Missing: []int{}, Boards: []boardReport{}, Error: "settings.json does not exist"},
+		},
+		{
+			name: "failed and skipped boards",
+			summary: extractSummary{
+				total:     4,
+				successes: 2,
+				failures:  1,
+				duration:  1500 * time.Millisecond,
+				boards: []boardReport{
+					{Board: 1, Status: boardExtracted},
+					{Board: 2, Status: boardFailed},
+					{Board: 3, Status: boardSkipped},
+					{Board: 4, Status: boardExtracted},
+				},
+			},
+			want: runReport{
+				Url:        "https://example.com/t1/",
+				DurationMs: 1500,
+				Total:      4,
+				Extracted:  2,
+				Failed:     1,
+				Skipped:    1,
+				Missing:    []int{2},
+				Boards: []boardReport{
+					{Board: 1, Status: boardExtracted},
+					{Board: 2, Status: boardFailed},
+					{Board: 3, Status: boardSkipped},
+					{Board: 4, Status: boardExtracted},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := newRunReport("https://example.com/t1/", tt.summary, tt.err); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("newRunReport() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_progressBar(t *testing.T) {
+	var b bytes.Buffer
+	bar := newProgressBar(&b, 4)
+	if want := "\r[" + strings.Repeat(".", progressBarWidth) + "] 0/4 boards"; b.String() != want {
+		t.Fatalf("new progress bar = %q, want %q", b.String(), want)
+	}
+
+	b.Reset()
+	bar.add(false)
+	bar.add(true)
+	// the bar is redrawn after every board, the last one shows both of them
+	last := b.String()[strings.LastIndex(b.String(), "\r"):]
+	if want := "\r[" + strings.Repeat("#", 15) + strings.Repeat(".", 15) + "] 2/4 boards, 1 failed, "; !strings.HasPrefix(last, want) {
+		t.Errorf("progress bar = %q, want prefix %q", last, want)
+	}
+	if !strings.Contains(last, "boards/s, ETA ") {
+		t.Errorf("progress bar = %q, want the rate and ETA", last)
+	}
+
+	b.Reset()
+	_, _ = bar.Write([]byte("Failed to extract Board 2\n"))
+	if want := "\r\033[KFailed to extract Board 2\n\r["; !strings.HasPrefix(b.String(), want) {
+		t.Errorf("log line with the bar = %q, want prefix %q", b.String(), want)
+	}
+
+	b.Reset()
+	bar.finish()
+	if b.String() != "\r\033[K" {
+		t.Errorf("finished progress bar = %q, want the line cleared", b.String())
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/fe-dox/go-pbn"
+	"github.com/fe-dox/tc-pbn-extractor/internal/export"
+	"github.com/fe-dox/tc-pbn-extractor/internal/render"
+)
+
+func runRender(args []string) {
+	fs := flag.NewFlagSet("render", flag.ExitOnError)
+	var boardNumber int
+	fs.IntVar(&boardNumber, "board", 0, "Board number (as numbered in TC) to render")
+	var output string
+	fs.StringVar(&output, "out", "", "File to write diagram to, if empty will write to <event-name>-<board>.<format>")
+	var writeToStdOut bool
+	fs.BoolVar(&writeToStdOut, "stdout", false, "Write diagram to stdout instead of file")
+	var format string
+	fs.StringVar(&format, "format", "svg", "Diagram format, svg or png")
+	var themeName string
+	fs.StringVar(&themeName, "theme", "light", fmt.Sprintf("Diagram theme, one of %v", render.Themes()))
+	var suitColours string
+	fs.StringVar(&suitColours, "suit-colours", "", "Suit colours in spades,hearts,diamonds,clubs order, e.g. #000000,#c62828,#ef6c00,#2e7d32")
+	var scale float64
+	fs.Float64Var(&scale, "scale", 1, "PNG scale factor")
+	var ef extractorFlags
+	ef.register(fs)
+	var cf configFlags
+	cf.register(fs)
+	var baseUrl string
+	fs.StringVar(&baseUrl, "url", "", "URL of the tournament")
+
+	fs.Usage = commandUsage(fs, "render -board <number> [options] <url>", "Render a diagram of a single board.")
+	_ = fs.Parse(args)
+	cf.apply(fs)
+
+	baseUrl, ok := baseUrlFromArgs(fs, baseUrl)
+	if !ok {
+		return
+	}
+	if boardNumber == 0 {
+		fs.Usage()
+		return
+	}
+	if format != "svg" && format != "png" {
+		exitf(exitInvalidInput, "Invalid format %q, expected svg or png\n", format)
+		return
+	}
+	theme, err := render.ThemeByName(themeName)
+	if err != nil {
+		exitf(exitInvalidInput, "Invalid theme %q: %v\n", themeName, err)
+		return
+	}
+	if suitColours != "" {
+		theme, err = theme.WithSuitColours(suitColours)
+		if err != nil {
+			exitf(exitInvalidInput, "%v\n", err)
+			return
+		}
+	}
+
+	ext := ef.extractor()
+	boards, err := ext.ExtractOneFromUrl(baseUrl, boardNumber)
+	if err != nil {
+		exitf(exitBoardFailed, "Failed to extract Board %d: %v\n", boardNumber, err)
+		return
+	}
+
+	content, err := renderDiagram(boards[0], format, theme, scale)
+	if err != nil {
+		exitf(exitOutputFailed, "Failed to render Board %d: %v\n", boardNumber, err)
+		return
+	}
+	if writeToStdOut {
+		_, err = os.Stdout.Write(content)
+		if err != nil {
+			exitf(exitOutputFailed, "Failed to write diagram: %v\n", err)
+		}
+		return
+	}
+	if output == "" {
+		settings, err := ext.ExtractSettingsFromUrl(baseUrl)
+		if err != nil {
+			exitf(exitSettingsFailed, "Failed to extract settings: %v\n", err)
+			return
+		}
+		output = renderOutputName(settings.EventName, boardNumber, format)
+	}
+	err = os.WriteFile(output, content, 0o666)
+	if err != nil {
+		exitf(exitOutputFailed, "Failed to write file: %v\n", err)
+		return
+	}
+}
+
+// renderDiagram renders the whole diagram before anything is written, so a failure leaves no partial file behind.
+func renderDiagram(board pbn.Board, format string, theme render.Theme, scale float64) ([]byte, error) {
+	var b bytes.Buffer
+	var err error
+	if format == "png" {
+		err = render.PNG(&b, board, theme, scale)
+	} else {
+		err = render.SVG(&b, board, theme)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// renderOutputName is <event-name>-<board>.<format>, with the event name made safe for a file name.
+func renderOutputName(eventName string, boardNumber int, format string) string {
+	return expandOutputName("{event}-"+strconv.Itoa(boardNumber)+".{ext}", eventName, export.Format(format))
+}
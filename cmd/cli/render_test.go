@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/fe-dox/tc-pbn-extractor/internal/render"
+)
+
+func Test_renderOutputName(t *testing.T) {
+	tests := []struct {
+		name      string
+		eventName string
+		format    string
+		want      string
+	}{
+		{name: "plain", eventName: "Test Pairs", format: "svg", want: "Test Pairs-7.svg"},
+		{name: "path separators", eventName: " Club/Pairs\\2023 ", format: "png", want: "Club_Pairs_2023-7.png"},
+		{name: "parent directory", eventName: "../Pairs", format: "svg", want: ".._Pairs-7.svg"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := renderOutputName(tt.eventName, 7, tt.format); got != tt.want {
+				t.Errorf("renderOutputName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_renderDiagram(t *testing.T) {
+	theme, _ := render.ThemeByName("light")
+	for _, format := range []string{"svg", "png"} {
+		t.Run(format, func(t *testing.T) {
+			content, err := renderDiagram(testBoard(7), format, theme, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var b bytes.Buffer
+			if format == "png" {
+				_ = render.PNG(&b, testBoard(7), theme, 1)
+			} else {
+				_ = render.SVG(&b, testBoard(7), theme)
+			}
+			if !bytes.Equal(content, b.Bytes()) {
+				t.Errorf("renderDiagram() differs from rendering straight to a writer")
+			}
+		})
+	}
+}
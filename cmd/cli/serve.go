@@ -0,0 +1,63 @@
+package main
+
+import (
+	"flag"
+	"time"
+
+	"github.com/fe-dox/tc-pbn-extractor/internal/app"
+	"github.com/fe-dox/tc-pbn-extractor/internal/bolt"
+	"github.com/fe-dox/tc-pbn-extractor/internal/config"
+	"github.com/fe-dox/tc-pbn-extractor/internal/data"
+)
+
+func runServe(args []string) {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	var ef extractorFlags
+	ef.register(fs)
+	var cf configFlags
+	cf.register(fs)
+	var addr string
+	fs.StringVar(&addr, "addr", app.DefaultAddr, "Address for the HTTP API to listen on")
+	var cache string
+	fs.StringVar(&cache, "cache", app.CacheRedis, "Where jobs and results are kept: redis, memory for a single instance without Redis, or bolt for a database file keeping history")
+	var cacheMemory int
+	fs.IntVar(&cacheMemory, "cache-memory", app.DefaultCacheMemory, "Memory limit of the memory cache in MiB")
+	var database string
+	fs.StringVar(&database, "database", app.DefaultDatabase, "Database file of the bolt cache")
+	var retention time.Duration
+	fs.DurationVar(&retention, "retention", bolt.DefaultRetention, "How long the bolt cache keeps finished jobs")
+	var redisUrl string
+	fs.StringVar(&redisUrl, "redis", app.DefaultRedisUrl, "Redis URL used to cache jobs and results")
+	var queue string
+	fs.StringVar(&queue, "queue", "", "Job queue: memory for workers in this process, or redis for workers started with tcpbn worker. If empty jobs are extracted as they are accepted")
+	var workers int
+	fs.IntVar(&workers, "workers", 0, "Workers extracting queued jobs in this process, 0 means one with the memory queue and none with the redis queue")
+	var resultTTL time.Duration
+	fs.DurationVar(&resultTTL, "result-ttl", data.DefaultResultTTL, "How long finished jobs are kept")
+	var processingTTL time.Duration
+	fs.DurationVar(&processingTTL, "processing-ttl", data.DefaultProcessingTTL, "How long a job may be processing before it can be queued again")
+	fs.Usage = commandUsage(fs, "serve [options]", "Start the HTTP API.")
+	_ = fs.Parse(args)
+	cf.apply(fs)
+
+	a, err := app.NewAppFromConfig(config.Profile{
+		Extractor: config.Extractor{UserAgent: ef.userAgent, Timeout: config.Duration(ef.timeout), Rate: config.Duration(ef.rate), Retries: ef.retries},
+		Server: config.Server{
+			Addr:          addr,
+			Cache:         cache,
+			CacheMemory:   cacheMemory,
+			Database:      database,
+			Retention:     config.Duration(retention),
+			Redis:         redisUrl,
+			Queue:         queue,
+			Workers:       workers,
+			ResultTTL:     config.Duration(resultTTL),
+			ProcessingTTL: config.Duration(processingTTL),
+		},
+	})
+	if err != nil {
+		exitf(startupExitCode(err), "Failed to start server: %v\n", err)
+		return
+	}
+	a.Run()
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"flag"
+	"strconv"
+
+	"github.com/fe-dox/tc-pbn-extractor/internal/deal"
+)
+
+type transformFlags struct {
+	renumber  int
+	rotate    int
+	swapSides bool
+}
+
+func (tf *transformFlags) register(fs *flag.FlagSet) {
+	fs.IntVar(&tf.renumber, "renumber", 0, "Renumber boards consecutively starting from this number, dealer and vulnerability follow the new numbers and par is dropped where vulnerability changes, 0 keeps numbers")
+	fs.Func("rotate", "Rotate deals clockwise by 90, 180 or 270 degrees, dealer, vulnerability, double dummy and par move with the hands", func(s string) error {
+		degrees, err := strconv.Atoi(s)
+		if err != nil {
+			return err
+		}
+		if _, err = deal.Rotate(degrees); err != nil {
+			return err
+		}
+		tf.rotate = degrees
+		return nil
+	})
+	fs.BoolVar(&tf.swapSides, "swap-sides", false, "Exchange NS and EW hands by moving every hand one seat clockwise, double dummy and par move with the hands")
+}
+
+// transform returns a fresh transform, renumbering starts over for every call. Seats are changed before boards
+// are renumbered, so with -renumber dealer and vulnerability always match the new board number and par is only
+// kept where the vulnerability it was computed for stays.
+func (tf *transformFlags) transform() deal.Transform {
+	var transforms []deal.Transform
+	if tf.rotate != 0 {
+		rotate, _ := deal.Rotate(tf.rotate)
+		transforms = append(transforms, rotate)
+	}
+	if tf.swapSides {
+		transforms = append(transforms, deal.SwapSides())
+	}
+	if tf.renumber != 0 {
+		transforms = append(transforms, deal.Renumber(tf.renumber))
+	}
+	return deal.Chain(transforms...)
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/fe-dox/go-pbn"
+	"github.com/fe-dox/tc-pbn-extractor/internal/deal"
+	"github.com/fe-dox/tc-pbn-extractor/internal/export"
+	"github.com/fe-dox/tc-pbn-extractor/internal/pbnfile"
+)
+
+var (
+	ErrUpdateNeedsPbn   = errors.New("-update only works with the pbn format")
+	ErrUpdateNeedsFile  = errors.New("-update can't be used with -stdout or -split")
+	ErrUpdateRenumbered = errors.New("-update matches boards by number and can't be used with -renumber")
+)
+
+// sourceTag records the TC board a board was extracted from in files written with -update, several boards as
+// played can come from one TC board.
+const sourceTag = "TcBoard"
+
+// existingBoard is a board of a previous run along with its TC board, zero when the file doesn't tell.
+type existingBoard struct {
+	board   pbn.Board
+	tcBoard int
+}
+
+// updateWriter merges newly extracted boards into the boards of a previous run and writes all of them as PBN in
+// board order on Flush. Boards already present are kept as they were. Every board is preceded by a sourceTag, so
+// the next update knows which TC boards it can skip.
+type updateWriter struct {
+	w       io.Writer
+	boards  []existingBoard
+	present map[int]bool
+}
+
+func newUpdateWriter(w io.Writer, existing []existingBoard) *updateWriter {
+	u := &updateWriter{w: w, boards: existing, present: make(map[int]bool, len(existing))}
+	for _, e := range existing {
+		u.present[e.board.Number] = true
+	}
+	return u
+}
+
+func (u *updateWriter) WriteBoard(board pbn.Board, source export.Source) error {
+	if u.present[board.Number] {
+		return nil
+	}
+	u.boards = append(u.boards, existingBoard{board: board, tcBoard: source.Board})
+	return nil
+}
+
+func (u *updateWriter) Flush() error {
+	sort.SliceStable(u.boards, func(i, j int) bool {
+		return u.boards[i].board.Number < u.boards[j].board.Number
+	})
+	for _, e := range u.boards {
+		if e.tcBoard != 0 {
+			err := pbn.WriteTag(sourceTag, strconv.Itoa(e.tcBoard), u.w)
+			if err != nil {
+				return err
+			}
+		}
+		err := e.board.Serialize(u.w, true)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readExistingBoards reads boards of a previous run, fill-missing placeholders are left out as they hold no
+// deal. A file that does not exist yet has no boards.
+func readExistingBoards(path string) ([]existingBoard, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	boards, sources, err := pbnfile.ReadTag(f, sourceTag)
+	if err != nil {
+		return nil, err
+	}
+	var existing []existingBoard
+	for i, board := range boards {
+		if deal.IsEmpty(board) {
+			continue
+		}
+		tcBoard, _ := strconv.Atoi(sources[i])
+		existing = append(existing, existingBoard{board: board, tcBoard: tcBoard})
+	}
+	return existing, nil
+}
+
+// extractedTcBoards lists TC boards whose boards are all in a previous run. Boards of files written without
+// -update don't tell their TC board, so those TC boards are downloaded again.
+func extractedTcBoards(existing []existingBoard) map[int]bool {
+	extracted := make(map[int]bool, len(existing))
+	for _, e := range existing {
+		if e.tcBoard != 0 {
+			extracted[e.tcBoard] = true
+		}
+	}
+	return extracted
+}
+
+func (opts *extractOptions) validateUpdate() error {
+	if !opts.update {
+		return nil
+	}
+	if opts.format != export.FormatPBN {
+		return ErrUpdateNeedsPbn
+	}
+	if opts.writeToStdOut || opts.splitOnDiscontinuation {
+		return ErrUpdateNeedsFile
+	}
+	if opts.transforms.renumber != 0 {
+		return ErrUpdateRenumbered
+	}
+	return nil
+}
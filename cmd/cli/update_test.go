@@ -0,0 +1,208 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fe-dox/go-pbn"
+	"github.com/fe-dox/tc-pbn-extractor/internal/export"
+	"github.com/fe-dox/tc-pbn-extractor/internal/extractor"
+	"github.com/fe-dox/tc-pbn-extractor/internal/pbnfile"
+)
+
+const testHands = `"HandN": {"Spades": "AK5", "Hearts": "Q72", "Diamonds": "A984", "Clubs": "J63"},
+"HandE": {"Spades": "QJ10", "Hearts": "AK8", "Diamonds": "765", "Clubs": "K982"},
+"HandS": {"Spades": "9876", "Hearts": "J109", "Diamonds": "KQ", "Clubs": "AQ104"},
+"HandW": {"Spades": "432", "Hearts": "6543", "Diamonds": "J1032", "Clubs": "75"}`
+
+// testProtocol is a TC protocol holding a distribution for every number as played.
+func testProtocol(number int, played ...int) string {
+	groups := make([]string, 0, len(played))
+	for _, p := range played {
+		groups = append(groups, fmt.Sprintf(`{"Distribution": {"Number": %d, "_numberAsPlayed": %d, "_handRecord": {%s}}, "Results": []}`, number, p, testHands))
+	}
+	return `{"ScoringGroups": [` + strings.Join(groups, ", ") + `]}`
+}
+
+func testBoard(number int) pbn.Board {
+	board := pbn.Board{Number: number, Hands: map[pbn.Direction]pbn.Hand{}}
+	for _, direction := range []pbn.Direction{pbn.North, pbn.East, pbn.South, pbn.West} {
+		board.Hands[direction] = pbn.Hand{pbn.Spades: []pbn.CardValue{pbn.A}}
+	}
+	return board
+}
+
+func Test_updateWriter(t *testing.T) {
+	var b bytes.Buffer
+	previous := testBoard(4)
+	previous.EventName = "previous"
+	u := newUpdateWriter(&b, []existingBoard{{board: testBoard(1)}, {board: previous, tcBoard: 2}})
+	updated := testBoard(4)
+	updated.EventName = "new"
+	for _, board := range []pbn.Board{testBoard(3), updated, testBoard(2)} {
+		if err := u.WriteBoard(board, export.Source{Board: 1}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := u.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	boards, sources, err := pbnfile.ReadTag(&b, sourceTag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var numbers []int
+	for _, board := range boards {
+		numbers = append(numbers, board.Number)
+	}
+	if want := []int{1, 2, 3, 4}; !reflect.DeepEqual(numbers, want) {
+		t.Errorf("updateWriter wrote boards %v, want %v", numbers, want)
+	}
+	if want := []string{"", "1", "1", "2"}; !reflect.DeepEqual(sources, want) {
+		t.Errorf("updateWriter wrote TC boards %q, want %q", sources, want)
+	}
+	if boards[3].EventName != "previous" {
+		t.Errorf("updateWriter replaced board 4 already present")
+	}
+}
+
+func Test_readExistingBoards(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "event.pbn")
+	if existing, err := readExistingBoards(path); err != nil || existing != nil {
+		t.Fatalf("readExistingBoards() of a missing file = %v, %v, want no boards", existing, err)
+	}
+	var b bytes.Buffer
+	u := newUpdateWriter(&b, nil)
+	_ = u.WriteBoard(testBoard(11), export.Source{Board: 1})
+	_ = u.WriteBoard(pbn.Board{Number: 2}, export.Source{Board: 2})
+	_ = u.WriteBoard(testBoard(3), export.Source{})
+	_ = u.Flush()
+	if err := os.WriteFile(path, b.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	existing, err := readExistingBoards(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got [][2]int
+	for _, e := range existing {
+		got = append(got, [2]int{e.board.Number, e.tcBoard})
+	}
+	if want := [][2]int{{3, 0}, {11, 1}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("readExistingBoards() = %v, want %v without the placeholder", got, want)
+	}
+	if extracted := extractedTcBoards(existing); !reflect.DeepEqual(extracted, map[int]bool{1: true}) {
+		t.Errorf("extractedTcBoards() = %v, want only TC board 1", extracted)
+	}
+}
+
+func Test_extractTournament_update(t *testing.T) {
+	var mu sync.Mutex
+	requested := map[string]int{}
+	third := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		requested[r.URL.Path]++
+		switch r.URL.Path {
+		case "/settings.json":
+			_, _ = w.Write([]byte(`{"BoardsNumbers": [1, 2, 3], "FullName": "Test Pairs"}`))
+		case "/p1.json":
+			_, _ = w.Write([]byte(testProtocol(1, 11)))
+		case "/p2.json":
+			_, _ = w.Write([]byte(testProtocol(2, 12, 13)))
+		case "/p3.json":
+			if !third {
+				http.NotFound(w, r)
+				return
+			}
+			_, _ = w.Write([]byte(testProtocol(3, 14)))
+		}
+	}))
+	defer server.Close()
+	output := filepath.Join(t.TempDir(), "event.pbn")
+	opts := extractOptions{
+		baseUrl:     server.URL + "/",
+		output:      output,
+		format:      export.FormatPBN,
+		fillMissing: true,
+		update:      true,
+		logger:      log.New(io.Discard, "", 0),
+	}
+	ext := extractor.NewExtractor("test", time.Second).WithRetries(0)
+
+	if _, err := extractTournament(ext, opts); err != nil {
+		t.Fatalf("extractTournament() error = %v", err)
+	}
+	// a longer file than the update writes, its end must not survive the rewrite
+	f, _ := os.OpenFile(output, os.O_APPEND|os.O_WRONLY, 0)
+	_, _ = f.WriteString("{ " + strings.Repeat("left over ", 100) + "}\n")
+	_ = f.Close()
+	mu.Lock()
+	third = true
+	requested = map[string]int{}
+	mu.Unlock()
+
+	summary, err := extractTournament(ext, opts)
+	if err != nil {
+		t.Fatalf("extractTournament() of an update error = %v", err)
+	}
+	if requested["/p1.json"] != 0 || requested["/p2.json"] != 0 || requested["/p3.json"] != 1 {
+		t.Errorf("update downloaded %v, want only the missing TC board 3", requested)
+	}
+	if summary.successes != 1 {
+		t.Errorf("update extracted %d boards, want 1", summary.successes)
+	}
+	raw, _ := os.ReadFile(output)
+	if bytes.Contains(raw, []byte("left over")) {
+		t.Errorf("update left the end of the previous file behind")
+	}
+	existing, _ := readExistingBoards(output)
+	var got [][2]int
+	for _, e := range existing {
+		got = append(got, [2]int{e.board.Number, e.tcBoard})
+	}
+	if want := [][2]int{{11, 1}, {12, 2}, {13, 2}, {14, 3}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("updated file boards = %v, want %v", got, want)
+	}
+}
+
+func Test_extractTournament_failedUpdateRemovesTemporaryFile(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "event.pbn")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/settings.json":
+			_, _ = w.Write([]byte(`{"BoardsNumbers": [1], "FullName": "Test Pairs"}`))
+		case "/p1.json":
+			// a directory taking the place of the output makes the final rename fail
+			_ = os.Mkdir(output, 0o755)
+			_, _ = w.Write([]byte(testProtocol(1, 1)))
+		}
+	}))
+	defer server.Close()
+	opts := extractOptions{
+		baseUrl: server.URL + "/",
+		output:  output,
+		format:  export.FormatPBN,
+		update:  true,
+		logger:  log.New(io.Discard, "", 0),
+	}
+	if _, err := extractTournament(extractor.NewExtractor("test", time.Second).WithRetries(0), opts); err == nil {
+		t.Fatalf("extractTournament() error = nil, want the rename onto a directory to fail")
+	}
+	if _, err := os.Stat(output + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("failed update left %s.tmp behind (stat error %v)", output, err)
+	}
+}
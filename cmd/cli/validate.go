@@ -0,0 +1,55 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/fe-dox/tc-pbn-extractor/internal/deal"
+	"github.com/fe-dox/tc-pbn-extractor/internal/pbnfile"
+)
+
+func runValidate(args []string) {
+	fs := flag.NewFlagSet("validate", flag.ExitOnError)
+	var strict bool
+	fs.BoolVar(&strict, "strict", false, "Treat warnings (missing double dummy data, dealer or vulnerability not matching board number) as errors")
+	fs.Usage = commandUsage(fs, "validate [options] <file.pbn>...", "Check that PBN files hold complete, consistent deals.")
+	_ = fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return
+	}
+	var boardCount, errorCount, warningCount int
+	for _, input := range fs.Args() {
+		f, err := os.Open(input)
+		if err != nil {
+			exitf(exitInvalidInput, "Failed to open file: %v\n", err)
+			return
+		}
+		boards, err := pbnfile.Read(f)
+		f.Close()
+		if err != nil {
+			exitf(exitInvalidInput, "Failed to read %s: %v\n", input, err)
+			return
+		}
+		for _, board := range boards {
+			boardCount++
+			for _, problem := range deal.Validate(board) {
+				level := "error"
+				if deal.IsWarning(problem) && !strict {
+					level = "warning"
+					warningCount++
+				} else {
+					errorCount++
+				}
+				fmt.Printf("%s: board %d: %s: %v\n", input, board.Number, level, problem)
+			}
+		}
+	}
+	log.Printf("Checked %d boards. %d errors, %d warnings.\n", boardCount, errorCount, warningCount)
+	if errorCount > 0 {
+		os.Exit(exitInvalidBoards)
+	}
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/fe-dox/go-pbn"
+	"github.com/fe-dox/tc-pbn-extractor/internal/deal"
+	"github.com/fe-dox/tc-pbn-extractor/internal/export"
+	"github.com/fe-dox/tc-pbn-extractor/internal/extractor"
+)
+
+type watchedBoard struct {
+	validators extractor.Validators
+	boards     []pbn.Board
+}
+
+type tournamentWatch struct {
+	ext                *extractor.Extractor
+	baseUrl            string
+	settingsValidators extractor.Validators
+	settings           extractor.TournamentSettings
+	boards             map[int]*watchedBoard
+}
+
+func runWatch(args []string) {
+	fs := flag.NewFlagSet("watch", flag.ExitOnError)
+	var output string
+	fs.StringVar(&output, "out", "", "File to write boards to, rewritten whenever a board is published or changes. {event} and {ext} are replaced with event name and format extension")
+	var dir string
+	fs.StringVar(&dir, "dir", "", "Directory to write output to when -out is a relative path")
+	var eventName string
+	fs.StringVar(&eventName, "event", "", "Event name to use in PBN, if empty will be extracted from tournament settings")
+	var generatorName string
+	fs.StringVar(&generatorName, "generator", "tc-pbn-extractor", "Generator name to use in PBN")
+	var format string
+	fs.StringVar(&format, "format", string(export.FormatPBN), fmt.Sprintf("Output format, one of %v", export.Formats))
+	var tf transformFlags
+	tf.register(fs)
+	var interval time.Duration
+	fs.DurationVar(&interval, "interval", 30*time.Second, "Delay between polls of the tournament")
+	var ef extractorFlags
+	ef.register(fs)
+	var cf configFlags
+	cf.register(fs)
+	var baseUrl string
+	fs.StringVar(&baseUrl, "url", "", "URL of the tournament")
+	fs.Usage = commandUsage(fs, "watch [options] <url>",
+		"Follow a tournament in progress and write boards as TC publishes them. Stops once every board is published.")
+	_ = fs.Parse(args)
+	cf.apply(fs)
+
+	baseUrl, ok := baseUrlFromArgs(fs, baseUrl)
+	if !ok {
+		return
+	}
+	if _, err := export.NewWriter(export.Format(format), io.Discard); err != nil {
+		exitf(exitInvalidInput, "%v\n", err)
+		return
+	}
+	if dir != "" {
+		err := os.MkdirAll(dir, 0755)
+		if err != nil {
+			exitf(exitOutputFailed, "Failed to create output directory: %v\n", err)
+			return
+		}
+	}
+
+	watch := tournamentWatch{ext: ef.extractor(), baseUrl: baseUrl, boards: make(map[int]*watchedBoard)}
+	watch.run(interval, watchOutput{
+		output:        output,
+		dir:           dir,
+		eventName:     eventName,
+		generatorName: generatorName,
+		format:        export.Format(format),
+		transform:     tf.transform(),
+	})
+}
+
+// watchOutput is where and how run writes boards, an empty eventName means the one from tournament settings.
+type watchOutput struct {
+	output        string
+	dir           string
+	eventName     string
+	generatorName string
+	format        export.Format
+	transform     deal.Transform
+}
+
+// run polls the tournament every interval and rewrites the output whenever a board changed, until every board is
+// published.
+func (t *tournamentWatch) run(interval time.Duration, out watchOutput) {
+	for {
+		changed, err := t.poll()
+		if err != nil {
+			log.Printf("Failed to poll tournament: %v\n", err)
+		}
+		if changed {
+			name := out.eventName
+			if name == "" {
+				name = t.settings.EventName
+			}
+			path := expandOutputName(out.output, t.settings.EventName, out.format)
+			if out.dir != "" && !filepath.IsAbs(path) {
+				path = filepath.Join(out.dir, path)
+			}
+			err = t.write(path, out.format, name, out.generatorName, out.transform)
+			if err != nil {
+				log.Printf("Failed to write output: %v\n", err)
+			}
+		}
+		if t.complete() {
+			log.Printf("All %d boards are published\n", len(t.settings.BoardsNumbers))
+			return
+		}
+		time.Sleep(interval)
+	}
+}
+
+// poll fetches settings and every board that changed since the previous poll and reports whether any board did.
+func (t *tournamentWatch) poll() (bool, error) {
+	settings, validators, err := t.ext.ExtractSettingsIfModified(t.baseUrl, t.settingsValidators)
+	switch {
+	case errors.Is(err, extractor.ErrNotModified):
+	case err != nil:
+		if len(t.settings.BoardsNumbers) == 0 {
+			return false, fmt.Errorf("failed to extract settings: %w", err)
+		}
+		log.Printf("Failed to refresh settings, using previous ones: %v\n", err)
+	default:
+		t.settings = settings
+		t.settingsValidators = validators
+	}
+
+	var changed bool
+	for _, number := range t.settings.BoardsNumbers {
+		board, ok := t.boards[number]
+		if !ok {
+			board = &watchedBoard{}
+			t.boards[number] = board
+		}
+		protocol, validators, err := t.ext.ExtractProtocolIfModified(t.baseUrl, number, board.validators)
+		if errors.Is(err, extractor.ErrNotModified) || errors.Is(err, extractor.ErrUnexpectedStatusCode) {
+			// not published yet
+			continue
+		}
+		if err != nil {
+			log.Printf("Failed to extract Board %d: %v\n", number, err)
+			continue
+		}
+		boards, err := extractor.BoardsFromProtocol(protocol)
+		if err != nil && !errors.Is(err, extractor.ErrNoDistributionData) {
+			log.Printf("Failed to read Board %d: %v\n", number, err)
+		}
+		if len(boards) == 0 {
+			// not published yet, validators are not kept so the board is downloaded again next time
+			continue
+		}
+		board.validators = validators
+		if reflect.DeepEqual(boards, board.boards) {
+			continue
+		}
+		if board.boards == nil {
+			log.Printf("Board %d published\n", number)
+		} else {
+			log.Printf("Board %d changed\n", number)
+		}
+		board.boards = boards
+		changed = true
+	}
+	return changed, nil
+}
+
+func (t *tournamentWatch) complete() bool {
+	if len(t.settings.BoardsNumbers) == 0 {
+		return false
+	}
+	for _, number := range t.settings.BoardsNumbers {
+		if board, ok := t.boards[number]; !ok || board.boards == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// write replaces output with all boards published so far, in board order. The file is swapped in with a rename so
+// readers never see it half written.
+func (t *tournamentWatch) write(output string, format export.Format, eventName string, generatorName string, transform deal.Transform) error {
+	numbers := make([]int, 0, len(t.boards))
+	for number, board := range t.boards {
+		if board.boards != nil {
+			numbers = append(numbers, number)
+		}
+	}
+	sort.Ints(numbers)
+
+	tmp := output + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	ew, err := export.NewWriter(format, f)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	for _, number := range numbers {
+		for _, board := range t.boards[number].boards {
+			board.EventName = eventName
+			board.Generator = generatorName
+			board = transform(board)
+			err = ew.WriteBoard(board, export.Source{Url: t.baseUrl, Board: number})
+			if err != nil {
+				f.Close()
+				return err
+			}
+		}
+	}
+	err = ew.Flush()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	err = f.Close()
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, output)
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fe-dox/tc-pbn-extractor/internal/deal"
+	"github.com/fe-dox/tc-pbn-extractor/internal/export"
+	"github.com/fe-dox/tc-pbn-extractor/internal/extractor"
+	"github.com/fe-dox/tc-pbn-extractor/internal/pbnfile"
+)
+
+func Test_tournamentWatch_run(t *testing.T) {
+	var mu sync.Mutex
+	requests := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests[r.URL.Path]++
+		n := requests[r.URL.Path]
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/settings.json":
+			_, _ = w.Write([]byte(`{"BoardsNumbers": [1, 2], "FullName": "Test Pairs"}`))
+		case "/p1.json":
+			// the protocol exists before its distribution is published, both under the same ETag
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", `"v1"`)
+			if n <= 2 {
+				_, _ = w.Write([]byte(`{"ScoringGroups": []}`))
+				return
+			}
+			_, _ = w.Write([]byte(testProtocol(1, 1)))
+		case "/p2.json":
+			if n == 1 {
+				http.NotFound(w, r)
+				return
+			}
+			_, _ = w.Write([]byte(testProtocol(2, 2)))
+		}
+	}))
+	defer server.Close()
+	dir := t.TempDir()
+	watch := tournamentWatch{
+		ext:     extractor.NewExtractor("test", time.Second).WithRetries(0),
+		baseUrl: server.URL + "/",
+		boards:  make(map[int]*watchedBoard),
+	}
+
+	done := make(chan struct{})
+	go func() {
+		watch.run(time.Millisecond, watchOutput{
+			output:    "{event}.{ext}",
+			dir:       dir,
+			format:    export.FormatPBN,
+			transform: deal.Chain(),
+		})
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("watch did not see every board published")
+	}
+
+	f, err := os.Open(filepath.Join(dir, "Test Pairs.pbn"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	boards, err := pbnfile.Read(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(boards) != 2 || boards[0].Number != 1 || boards[1].Number != 2 || boards[0].EventName != "Test Pairs" {
+		t.Errorf("watch wrote %+v, want boards 1 and 2 of Test Pairs", boards)
+	}
+	if requests["/p1.json"] != 3 {
+		t.Errorf("board 1 downloaded %d times, want 3 with validators kept only once it was published", requests["/p1.json"])
+	}
+}
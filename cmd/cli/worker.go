@@ -0,0 +1,46 @@
+package main
+
+import (
+	"flag"
+	"time"
+
+	"github.com/fe-dox/tc-pbn-extractor/internal/app"
+	"github.com/fe-dox/tc-pbn-extractor/internal/config"
+	"github.com/fe-dox/tc-pbn-extractor/internal/data"
+)
+
+func runWorker(args []string) {
+	fs := flag.NewFlagSet("worker", flag.ExitOnError)
+	var ef extractorFlags
+	ef.register(fs)
+	var cf configFlags
+	cf.register(fs)
+	var redisUrl string
+	fs.StringVar(&redisUrl, "redis", app.DefaultRedisUrl, "Redis URL of the job queue and results cache shared with the HTTP API")
+	var workers int
+	fs.IntVar(&workers, "workers", 1, "How many jobs to extract at the same time")
+	var resultTTL time.Duration
+	fs.DurationVar(&resultTTL, "result-ttl", data.DefaultResultTTL, "How long finished jobs are kept")
+	var processingTTL time.Duration
+	fs.DurationVar(&processingTTL, "processing-ttl", data.DefaultProcessingTTL, "How long a job may be processing before it can be queued again")
+	fs.Usage = commandUsage(fs, "worker [options]", "Extract jobs queued by tcpbn serve -queue redis, until interrupted.")
+	_ = fs.Parse(args)
+	cf.apply(fs)
+
+	w, err := app.NewWorkerFromConfig(config.Profile{
+		Extractor: config.Extractor{UserAgent: ef.userAgent, Timeout: config.Duration(ef.timeout), Rate: config.Duration(ef.rate), Retries: ef.retries},
+		Server: config.Server{
+			Cache:         app.CacheRedis,
+			Redis:         redisUrl,
+			Queue:         app.QueueRedis,
+			Workers:       workers,
+			ResultTTL:     config.Duration(resultTTL),
+			ProcessingTTL: config.Duration(processingTTL),
+		},
+	})
+	if err != nil {
+		exitf(startupExitCode(err), "Failed to start worker: %v\n", err)
+		return
+	}
+	w.Run()
+}
@@ -1,30 +1,252 @@
 package app
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/fe-dox/tc-pbn-extractor/internal/bolt"
+	"github.com/fe-dox/tc-pbn-extractor/internal/config"
+	"github.com/fe-dox/tc-pbn-extractor/internal/data"
+	"github.com/fe-dox/tc-pbn-extractor/internal/extractor"
+	"github.com/fe-dox/tc-pbn-extractor/internal/memory"
+	"github.com/fe-dox/tc-pbn-extractor/internal/redis"
 	"github.com/gin-gonic/gin"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
-type ParseRequest struct {
-	Url string
-}
+const (
+	DefaultAddr        = ":8080"
+	DefaultRedisUrl    = "redis://localhost:6379/0"
+	DefaultUserAgent   = "tc-pbn-extractor"
+	DefaultTimeout     = time.Second
+	DefaultCacheMemory = 256
+	DefaultDatabase    = "tcpbn.db"
+)
+
+// Results cache backends selectable with config.Server.Cache.
+const (
+	CacheRedis  = "redis"
+	CacheMemory = "memory"
+	CacheBolt   = "bolt"
+)
+
+// Job queues selectable with config.Server.Queue, without one jobs are extracted by the server that accepted them.
+const (
+	QueueMemory = "memory"
+	QueueRedis  = "redis"
+)
+
+var (
+	ErrUnknownCache     = errors.New("unknown results cache, use redis, memory or bolt")
+	ErrUnknownQueue     = errors.New("unknown job queue, use memory or redis")
+	ErrQueueSharedCache = errors.New("the redis queue is shared by processes, it needs the redis cache")
+	ErrWorkerQueue      = errors.New("workers need the redis queue")
+)
 
 type App struct {
+	ec       *ExtractionController
+	addr     string
+	recovery time.Duration
+	workers  int
 }
 
-func (a *App) Run() {
+func NewApp(ec *ExtractionController, addr string) *App {
+	if addr == "" {
+		addr = DefaultAddr
+	}
+	return &App{ec: ec, addr: addr}
+}
+
+// NewAppFromConfig wires the extractor, results cache, job queue and controller from the extractor and server
+// settings of a config profile. Jobs of the memory queue are extracted by workers of the server, one unless
+// configured otherwise, the redis queue has no workers in the server by default.
+func NewAppFromConfig(profile config.Profile) (*App, error) {
+	es, processingTTL, err := newExtractionService(profile)
+	if err != nil {
+		return nil, err
+	}
+	app := NewApp(NewExtractionController(es), profile.Server.Addr).WithRecovery(processingTTL)
+	if profile.Server.Queue == QueueMemory && profile.Server.Workers == 0 {
+		app.workers = 1
+	} else if profile.Server.Queue != "" {
+		app.workers = profile.Server.Workers
+	}
+	return app, nil
+}
+
+// newExtractionService wires the extractor, results cache and job queue, it returns the processing TTL of the
+// results cache along with the service.
+func newExtractionService(profile config.Profile) (ExtractionService, time.Duration, error) {
+	rc, err := newResultsCache(profile.Server)
+	if err != nil {
+		return ExtractionService{}, 0, err
+	}
+	userAgent := profile.Extractor.UserAgent
+	if userAgent == "" {
+		userAgent = DefaultUserAgent
+	}
+	timeout := time.Duration(profile.Extractor.Timeout)
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+	ex := extractor.NewExtractor(userAgent, timeout).
+		WithRateLimit(time.Duration(profile.Extractor.Rate)).
+		WithRetries(profile.Extractor.Retries)
+	processingTTL := time.Duration(profile.Server.ProcessingTTL)
+	if processingTTL == 0 {
+		processingTTL = data.DefaultProcessingTTL
+	}
+	es := NewExtractionService(ex, rc).WithHeartbeat(processingTTL / 3)
+	q, err := newJobQueue(profile.Server, processingTTL)
+	if err != nil {
+		return ExtractionService{}, 0, err
+	}
+	if q != nil {
+		es = es.WithQueue(q)
+	}
+	return es, processingTTL, nil
+}
+
+// WithRecovery makes the server look for jobs abandoned by vanished workers every interval, see
+// ExtractionService.RecoverAbandoned.
+func (a *App) WithRecovery(interval time.Duration) *App {
+	a.recovery = interval
+	return a
+}
+
+// newResultsCache picks the results cache backend, Redis unless another one is configured. Cache memory is
+// given in MiB.
+func newResultsCache(server config.Server) (data.ResultsCache, error) {
+	resultTTL := time.Duration(server.ResultTTL)
+	processingTTL := time.Duration(server.ProcessingTTL)
+	switch server.Cache {
+	case "", CacheRedis:
+		rc, err := redis.NewResultsCache(redisUrl(server), resultTTL, processingTTL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+		}
+		return rc, nil
+	case CacheMemory:
+		cacheMemory := server.CacheMemory
+		if cacheMemory == 0 {
+			cacheMemory = DefaultCacheMemory
+		}
+		return memory.NewResultsCache(resultTTL, processingTTL, int64(cacheMemory)<<20), nil
+	case CacheBolt:
+		database := server.Database
+		if database == "" {
+			database = DefaultDatabase
+		}
+		rc, err := bolt.NewResultsCache(database, time.Duration(server.Retention), processingTTL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", database, err)
+		}
+		return rc, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownCache, server.Cache)
+	}
+}
+
+// newJobQueue picks the job queue, it is nil when none is configured. Deliveries of the redis queue are handed to
+// another worker once they are unacknowledged for the processing TTL, by then their claim has expired too.
+func newJobQueue(server config.Server, processingTTL time.Duration) (data.JobQueue, error) {
+	switch server.Queue {
+	case "":
+		return nil, nil
+	case QueueMemory:
+		return memory.NewJobQueue(), nil
+	case QueueRedis:
+		if server.Cache != "" && server.Cache != CacheRedis {
+			return nil, ErrQueueSharedCache
+		}
+		q, err := redis.NewJobQueue(redisUrl(server), consumerName(), processingTTL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+		}
+		return q, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownQueue, server.Queue)
+	}
+}
+
+func redisUrl(server config.Server) string {
+	if server.Redis == "" {
+		return DefaultRedisUrl
+	}
+	return server.Redis
+}
+
+// consumerName tells workers of the redis queue apart, it is unique per process.
+func consumerName() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "worker"
+	}
+	return fmt.Sprintf("%s-%d", hostname, os.Getpid())
+}
+
+func (a *App) Router() *gin.Engine {
 	router := gin.Default()
 	{
-		router.POST("parse", func(context *gin.Context) {
+		router.POST("jobs", a.ec.CreateJob)
+		router.GET("jobs", a.ec.ListJobs)
+		router.GET("jobs/:id", a.ec.GetJob)
+		router.DELETE("jobs/:id", a.ec.CancelJob)
+		router.GET("jobs/:id/events", a.ec.GetJobEvents)
+		router.GET("jobs/:id/download/:set", a.ec.DownloadBoardSet)
+		router.GET("jobs/:id/boards", a.ec.GetBoards)
+		router.GET("jobs/:id/boards/:board", a.ec.GetBoardDiagram)
+	}
+	registerWebUI(router)
+	return router
+}
 
-		})
+func (a *App) Run() {
+	if a.recovery > 0 {
+		go a.ec.es.RecoverPeriodically(a.recovery)
+	}
+	if a.workers > 0 {
+		go a.ec.es.Work(context.Background(), a.workers)
+	}
+	err := a.Router().Run(a.addr)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
 
-		router.GET("download/:fileId", func(context *gin.Context) {
+// Worker extracts jobs enqueued by servers sharing its Redis, any number of workers may run next to each other.
+type Worker struct {
+	es       ExtractionService
+	workers  int
+	recovery time.Duration
+}
 
-		})
+// NewWorkerFromConfig wires a worker from the extractor and server settings of a config profile, the profile
+// has to select the redis queue.
+func NewWorkerFromConfig(profile config.Profile) (*Worker, error) {
+	if profile.Server.Queue != QueueRedis {
+		return nil, ErrWorkerQueue
 	}
-	err := router.Run()
+	es, processingTTL, err := newExtractionService(profile)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
+	workers := profile.Server.Workers
+	if workers == 0 {
+		workers = 1
+	}
+	return &Worker{es: es, workers: workers, recovery: processingTTL}, nil
+}
+
+// Run extracts jobs until the process is interrupted, jobs being extracted then are finished first.
+func (w *Worker) Run() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go w.es.RecoverPeriodically(w.recovery)
+	log.Printf("Extracting queued jobs with %d workers", w.workers)
+	w.es.Work(ctx, w.workers)
 }
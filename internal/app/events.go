@@ -0,0 +1,100 @@
+package app
+
+import (
+	"sync"
+
+	"github.com/fe-dox/tc-pbn-extractor/internal/data"
+)
+
+const (
+	EventProgress        = "progress"
+	EventBoardFetched    = "fetched"
+	EventBoardFailed     = "failed"
+	EventBoardSerialized = "serialized"
+	EventDropped         = "dropped"
+	EventDone            = "done"
+	EventError           = "error"
+)
+
+// JobEvent is something that happened to a running job. Board events carry the board number as numbered in TC
+// for fetched and failed boards, and as played for serialized ones. Dropped events count events a slow
+// subscriber missed.
+type JobEvent struct {
+	Type     string         `json:"-"`
+	Board    int            `json:"board,omitempty"`
+	Error    string         `json:"error,omitempty"`
+	Dropped  int            `json:"dropped,omitempty"`
+	Progress *data.Progress `json:"progress,omitempty"`
+}
+
+const subscriberBuffer = 64
+
+// eventBroker fans events of jobs running in this process out to subscribers. Slow subscribers miss events
+// rather than hold up extraction, they get a dropped event counting them once there is room again or the job
+// finishes.
+type eventBroker struct {
+	mu          sync.Mutex
+	subscribers map[string]map[chan JobEvent]*subscriber
+}
+
+// subscriber counts events dropped since the last dropped event. Its channel has room for one more event than
+// publish fills, so the final dropped event always fits.
+type subscriber struct {
+	dropped int
+}
+
+func newEventBroker() *eventBroker {
+	return &eventBroker{subscribers: make(map[string]map[chan JobEvent]*subscriber)}
+}
+
+// subscribe returns a channel of events of a job, closed when the job finishes, and a function to stop listening.
+func (b *eventBroker) subscribe(jobHash string) (<-chan JobEvent, func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	ch := make(chan JobEvent, subscriberBuffer+1)
+	if b.subscribers[jobHash] == nil {
+		b.subscribers[jobHash] = make(map[chan JobEvent]*subscriber)
+	}
+	b.subscribers[jobHash][ch] = &subscriber{}
+	return ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := b.subscribers[jobHash][ch]; ok {
+			delete(b.subscribers[jobHash], ch)
+			close(ch)
+		}
+		if len(b.subscribers[jobHash]) == 0 {
+			delete(b.subscribers, jobHash)
+		}
+	}
+}
+
+// publish never blocks, only publish and finish send and both hold the lock, so a channel with room keeps it.
+func (b *eventBroker) publish(jobHash string, event JobEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch, s := range b.subscribers[jobHash] {
+		if s.dropped > 0 && len(ch) < subscriberBuffer {
+			ch <- JobEvent{Type: EventDropped, Dropped: s.dropped}
+			s.dropped = 0
+		}
+		if len(ch) < subscriberBuffer {
+			ch <- event
+		} else {
+			s.dropped++
+		}
+	}
+}
+
+// finish closes channels of all subscribers of a job, after a dropped event for those that missed some.
+func (b *eventBroker) finish(jobHash string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch, s := range b.subscribers[jobHash] {
+		if s.dropped > 0 {
+			ch <- JobEvent{Type: EventDropped, Dropped: s.dropped}
+		}
+		close(ch)
+	}
+	delete(b.subscribers, jobHash)
+}
@@ -0,0 +1,72 @@
+package app
+
+import "testing"
+
+func TestEventBroker_Unsubscribe(t *testing.T) {
+	b := newEventBroker()
+	_, first := b.subscribe("job")
+	_, second := b.subscribe("job")
+	first()
+	if len(b.subscribers["job"]) != 1 {
+		t.Fatalf("subscribers after one unsubscribed = %d, want 1", len(b.subscribers["job"]))
+	}
+	second()
+	first()
+	if _, ok := b.subscribers["job"]; ok {
+		t.Errorf("subscribers of a job kept after all unsubscribed")
+	}
+}
+
+func TestEventBroker_Dropped(t *testing.T) {
+	tests := []struct {
+		name string
+		// read is how many events are read before more are published
+		read      int
+		published int
+		want      []JobEvent
+	}{
+		{
+			name:      "reported once the job finishes",
+			published: subscriberBuffer + 3,
+			want:      []JobEvent{{Type: EventDropped, Dropped: 3}},
+		},
+		{
+			name:      "reported before the next event with room",
+			read:      subscriberBuffer,
+			published: subscriberBuffer + 2,
+			want:      []JobEvent{{Type: EventDropped, Dropped: 2}, {Type: EventProgress, Board: subscriberBuffer + 3}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newEventBroker()
+			events, unsubscribe := b.subscribe("job")
+			defer unsubscribe()
+			for i := 1; i <= tt.published; i++ {
+				b.publish("job", JobEvent{Type: EventProgress, Board: i})
+			}
+			for i := 0; i < tt.read; i++ {
+				<-events
+			}
+			if tt.read > 0 {
+				b.publish("job", JobEvent{Type: EventProgress, Board: tt.published + 1})
+			}
+			b.finish("job")
+
+			var got []JobEvent
+			for event := range events {
+				if event.Type != EventProgress || event.Board > subscriberBuffer {
+					got = append(got, event)
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("events past the buffer = %+v, want %+v", got, tt.want)
+			}
+			for i := range got {
+				if got[i].Type != tt.want[i].Type || got[i].Dropped != tt.want[i].Dropped || got[i].Board != tt.want[i].Board {
+					t.Errorf("event %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
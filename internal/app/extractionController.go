@@ -1,15 +1,383 @@
 package app
 
-import "github.com/gin-gonic/gin"
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/fe-dox/tc-pbn-extractor/internal/data"
+	"github.com/fe-dox/tc-pbn-extractor/internal/export"
+	"github.com/fe-dox/tc-pbn-extractor/internal/render"
+	"github.com/gin-gonic/gin"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
 
 type ExtractionController struct {
 	es ExtractionService
 }
 
+func NewExtractionController(es ExtractionService) *ExtractionController {
+	return &ExtractionController{es: es}
+}
+
+type JobResponse struct {
+	Id        string         `json:"id"`
+	Status    string         `json:"status"`
+	EventName string         `json:"eventName,omitempty"`
+	Success   bool           `json:"success"`
+	Boards    int            `json:"boards"`
+	Errors    []string       `json:"errors,omitempty"`
+	Downloads []string       `json:"downloads,omitempty"`
+	Progress  *data.Progress `json:"progress,omitempty"`
+}
+
+// CreateJob queues extraction of the data.Options in the body. Queueing a job that is already processing or done
+// is not an error, the client gets the job described the way GetJob does.
 func (ec *ExtractionController) CreateJob(ctx *gin.Context) {
+	var options data.Options
+	if err := ctx.ShouldBindJSON(&options); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	id, err := ec.es.QueueJob(options)
+	if err != nil && !errors.Is(err, ErrJobAlreadyProcessing) {
+		status := http.StatusInternalServerError
+		if errors.Is(err, ErrInvalidOptions) {
+			status = http.StatusBadRequest
+		}
+		ctx.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	response, status, err := ec.jobResponse(id)
+	if err != nil {
+		ctx.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.Header("Location", "/jobs/"+id)
+	ctx.JSON(status, response)
+}
+
+const (
+	defaultRecentLimit = 20
+	maxRecentLimit     = 200
+)
 
+// ListJobs lists recent extractions, optionally of a single tournament given by the baseUrl query parameter.
+func (ec *ExtractionController) ListJobs(ctx *gin.Context) {
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", strconv.Itoa(defaultRecentLimit)))
+	if err != nil || limit < 1 || limit > maxRecentLimit {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit must be between 1 and %d", maxRecentLimit)})
+		return
+	}
+	entries, err := ec.es.Recent(ctx.Query("baseUrl"), limit)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, ErrHistoryUnsupported) {
+			status = http.StatusNotImplemented
+		}
+		ctx.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"jobs": entries})
 }
 
 func (ec *ExtractionController) GetJob(ctx *gin.Context) {
+	response, status, err := ec.jobResponse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(status, response)
+}
+
+// CancelJob stops a processing job, it responds like GetJob. The job stays processing until the boards
+// extracted so far are saved, then it is cancelled.
+func (ec *ExtractionController) CancelJob(ctx *gin.Context) {
+	id := ctx.Param("id")
+	err := ec.es.CancelJob(id)
+	if err != nil {
+		ctx.JSON(jobErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	response, status, err := ec.jobResponse(id)
+	if err != nil {
+		ctx.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(status, response)
+}
+
+// jobResponse describes a job along with the HTTP status matching its state, 202 while it is queued or processing
+// and 200 once it is done or cancelled.
+func (ec *ExtractionController) jobResponse(id string) (JobResponse, int, error) {
+	jobStatus, result, err := ec.es.GetJobWithStatus(id)
+	if err != nil && !errors.Is(err, ErrJobIsStillBeingProcessed) {
+		return JobResponse{}, jobErrorStatus(err), err
+	}
+	progress, progressErr := ec.es.GetProgress(id)
+	if progressErr != nil {
+		return JobResponse{}, http.StatusInternalServerError, progressErr
+	}
+	var jobProgress *data.Progress
+	if !progress.Started.IsZero() {
+		jobProgress = &progress
+	}
+	if err != nil {
+		return JobResponse{Id: id, Status: jobStatus.String(), Progress: jobProgress}, http.StatusAccepted, nil
+	}
+	response := JobResponse{
+		Id:        id,
+		Status:    jobStatus.String(),
+		EventName: result.EventName,
+		Success:   result.Success,
+		Boards:    len(result.Boards),
+		Errors:    result.Errors,
+		Downloads: make([]string, 0, len(result.BoardSets)),
+		Progress:  jobProgress,
+	}
+	for i := range result.BoardSets {
+		response.Downloads = append(response.Downloads, fmt.Sprintf("/jobs/%s/download/%d", id, i+1))
+	}
+	return response, http.StatusOK, nil
+}
+
+// eventsPollInterval is how often GetJobEvents checks the results cache, it catches jobs running in other
+// processes and keeps idle connections alive.
+const eventsPollInterval = 2 * time.Second
+
+// GetJobEvents streams events of a job as Server-Sent Events. It ends with a done event describing the job the
+// way GetJob does, or an error event. The job state is sent again after subscribing, so events published in
+// between are not missed.
+func (ec *ExtractionController) GetJobEvents(ctx *gin.Context) {
+	id := ctx.Param("id")
+	_, status, err := ec.jobResponse(id)
+	if err != nil {
+		ctx.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	events, unsubscribe := ec.es.Subscribe(id)
+	defer unsubscribe()
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("X-Accel-Buffering", "no")
+	keepOpen := ec.sendJobState(ctx, id)
+	ctx.Writer.Flush()
+	if !keepOpen {
+		return
+	}
+	ticker := time.NewTicker(eventsPollInterval)
+	defer ticker.Stop()
+	ctx.Stream(func(w io.Writer) bool {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				// a worker that lost its claim closes the channel while the job goes on elsewhere, the ticker
+				// follows it from now on
+				events = nil
+				return ec.sendJobState(ctx, id)
+			}
+			ctx.SSEvent(event.Type, event)
+			return true
+		case <-ticker.C:
+			return ec.sendJobState(ctx, id)
+		case <-ctx.Request.Context().Done():
+			return false
+		}
+	})
+}
+
+// sendJobState sends progress of a processing job and reports whether the stream should go on, finished and
+// failed jobs end it.
+func (ec *ExtractionController) sendJobState(ctx *gin.Context, id string) bool {
+	response, status, err := ec.jobResponse(id)
+	switch {
+	case err != nil:
+		ctx.SSEvent(EventError, JobEvent{Error: err.Error()})
+		return false
+	case status == http.StatusOK:
+		ctx.SSEvent(EventDone, response)
+		return false
+	default:
+		ctx.SSEvent(EventProgress, JobEvent{Progress: response.Progress})
+		return true
+	}
+}
+
+var ErrBoardSetNotFound = errors.New("board set not found")
+
+var formatContentTypes = map[export.Format]string{
+	export.FormatPBN:      "application/x-pbn",
+	export.FormatJSON:     "application/json",
+	export.FormatNDJSON:   "application/x-ndjson",
+	export.FormatCSV:      "text/csv; charset=utf-8",
+	export.FormatText:     "text/plain; charset=utf-8",
+	export.FormatMarkdown: "text/markdown; charset=utf-8",
+}
+
+// DownloadBoardSet sends a board set of a finished job as an attachment, sets are numbered from 1. The format
+// query parameter picks any export format, PBN is the default.
+func (ec *ExtractionController) DownloadBoardSet(ctx *gin.Context) {
+	format := export.Format(ctx.DefaultQuery("format", string(export.FormatPBN)))
+	contentType, ok := formatContentTypes[format]
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": export.ErrUnknownFormat.Error()})
+		return
+	}
+	result, err := ec.es.GetJob(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(jobErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	set, err := strconv.Atoi(ctx.Param("set"))
+	if err != nil || set < 1 || set > len(result.BoardSets) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": ErrBoardSetNotFound.Error()})
+		return
+	}
+	content := []byte(result.BoardSets[set-1])
+	if format != export.FormatPBN {
+		content, err = exportBoards(format, result.BoardsOfSet(set-1))
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	fileName := boardSetFileName(result.EventName, set, len(result.BoardSets), format)
+	ctx.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": fileName}))
+	ctx.Data(http.StatusOK, contentType, content)
+}
+
+func exportBoards(format export.Format, encoded []json.RawMessage) ([]byte, error) {
+	boards, err := decodeBoards(encoded)
+	if err != nil {
+		return nil, err
+	}
+	var b bytes.Buffer
+	w, err := export.NewWriter(format, &b)
+	if err != nil {
+		return nil, err
+	}
+	for _, board := range boards {
+		pbnBoard := board.PBN()
+		pbnBoard.Generator = data.GENERATOR
+		err = w.WriteBoard(pbnBoard, board.Source)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = w.Flush()
+	if err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// decodeBoards reads boards of a result, which keeps them in the export JSON schema.
+func decodeBoards(encoded []json.RawMessage) ([]export.Board, error) {
+	boards := make([]export.Board, 0, len(encoded))
+	for _, raw := range encoded {
+		var board export.Board
+		err := json.Unmarshal(raw, &board)
+		if err != nil {
+			return nil, err
+		}
+		boards = append(boards, board)
+	}
+	return boards, nil
+}
+
+// boardSetFileName names files after the event, numbering them only when a job was split into several sets.
+func boardSetFileName(eventName string, set int, sets int, format export.Format) string {
+	name := export.SafeFileName(eventName)
+	if name == "" {
+		name = "boards"
+	}
+	if sets > 1 {
+		name = fmt.Sprintf("%s-%d", name, set)
+	}
+	return name + "." + format.Extension()
+}
+
+func (ec *ExtractionController) GetBoards(ctx *gin.Context) {
+	result, err := ec.es.GetJob(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(jobErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	boards, err := decodeBoards(result.Boards)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, export.NewDocument(result.EventName, data.GENERATOR, boards))
+}
+
+var ErrBoardNotFound = errors.New("board not found")
+
+// GetBoardDiagram renders a board of a finished job, the board parameter is the number as played followed by .svg or .png.
+func (ec *ExtractionController) GetBoardDiagram(ctx *gin.Context) {
+	rawBoard := ctx.Param("board")
+	format := strings.TrimPrefix(path.Ext(rawBoard), ".")
+	boardNumber, err := strconv.Atoi(strings.TrimSuffix(rawBoard, path.Ext(rawBoard)))
+	if err != nil || (format != "svg" && format != "png") {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": ErrBoardNotFound.Error()})
+		return
+	}
+	theme, err := render.ThemeByName(ctx.DefaultQuery("theme", "light"))
+	if err == nil && ctx.Query("suitColours") != "" {
+		theme, err = theme.WithSuitColours(ctx.Query("suitColours"))
+	}
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	result, err := ec.es.GetJob(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(jobErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	boards, err := decodeBoards(result.Boards)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	for _, board := range boards {
+		if board.Board != boardNumber {
+			continue
+		}
+		var b bytes.Buffer
+		if format == "png" {
+			err = render.PNG(&b, board.PBN(), theme, 1)
+		} else {
+			err = render.SVG(&b, board.PBN(), theme)
+		}
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		contentType := "image/svg+xml"
+		if format == "png" {
+			contentType = "image/png"
+		}
+		ctx.Data(http.StatusOK, contentType, b.Bytes())
+		return
+	}
+	ctx.JSON(http.StatusNotFound, gin.H{"error": ErrBoardNotFound.Error()})
+}
 
+func jobErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrJobNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrJobIsStillBeingProcessed):
+		return http.StatusAccepted
+	case errors.Is(err, ErrJobNotRunning):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
 }
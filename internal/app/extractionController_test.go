@@ -0,0 +1,316 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fe-dox/tc-pbn-extractor/internal/data"
+	"github.com/fe-dox/tc-pbn-extractor/internal/export"
+	"github.com/fe-dox/tc-pbn-extractor/internal/extractor"
+	"github.com/fe-dox/tc-pbn-extractor/internal/memory"
+	"github.com/gin-gonic/gin"
+)
+
+func newTestCache(t *testing.T) *memory.ResultsCache {
+	cache := memory.NewResultsCache(0, 0, 0)
+	t.Cleanup(cache.Close)
+	return cache
+}
+
+func newTestRouter(cache data.ResultsCache) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	es := NewExtractionService(extractor.NewExtractor("test", time.Second), cache)
+	return NewApp(NewExtractionController(es), "").Router()
+}
+
+func TestExtractionController_CreateJob(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want int
+		// status is the job state answered, empty for rejected requests
+		status string
+	}{
+		{name: "malformed json", body: `{`, want: http.StatusBadRequest},
+		{name: "missing url", body: `{"eventName":"Test"}`, want: http.StatusBadRequest},
+		{name: "unsupported scheme", body: `{"baseUrl":"ftp://example.com/t1/"}`, want: http.StatusBadRequest},
+		{name: "invalid selection", body: `{"baseUrl":"http://example.com/t1/","boardsRange":"1-x"}`, want: http.StatusBadRequest},
+		{name: "already processing", body: `{"baseUrl":"http://example.com/t1/"}`, want: http.StatusAccepted, status: "processing"},
+		{name: "already done", body: `{"baseUrl":"http://example.com/t2/"}`, want: http.StatusOK, status: "done"},
+		{name: "already cancelled", body: `{"baseUrl":"http://example.com/t3/"}`, want: http.StatusOK, status: "cancelled"},
+	}
+	cache := newTestCache(t)
+	_, _ = cache.Claim((&data.Options{BaseUrl: "http://example.com/t1/"}).Hash(), "other", data.Options{})
+	_ = cache.SaveResult((&data.Options{BaseUrl: "http://example.com/t2/"}).Hash(), data.Result{Success: true})
+	_ = cache.SaveCancelled((&data.Options{BaseUrl: "http://example.com/t3/"}).Hash(), data.Result{Success: true})
+	router := newTestRouter(cache)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/jobs", strings.NewReader(tt.body)))
+			if w.Code != tt.want {
+				t.Fatalf("POST /jobs status = %d, want %d (%s)", w.Code, tt.want, w.Body.String())
+			}
+			if tt.status == "" {
+				return
+			}
+			var job JobResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &job); err != nil || job.Status != tt.status {
+				t.Errorf("POST /jobs = %s, want a %s job", w.Body.String(), tt.status)
+			}
+		})
+	}
+}
+
+func TestExtractionController_GetJobAndDownload(t *testing.T) {
+	cache := newTestCache(t)
+	result := data.NewResult()
+	result.Success = true
+	result.EventName = "Club / Pairs"
+	result.AddBoardSet("[Board \"1\"]\n")
+	result.AddBoardSet("[Board \"2\"]\n")
+	_ = cache.SaveResult("done", *result)
+	_, _ = cache.Claim("running", "other", data.Options{})
+	router := newTestRouter(cache)
+
+	tests := []struct {
+		name        string
+		path        string
+		want        int
+		disposition string
+	}{
+		{name: "unknown job", path: "/jobs/missing", want: http.StatusNotFound},
+		{name: "processing job", path: "/jobs/running", want: http.StatusAccepted},
+		{name: "finished job", path: "/jobs/done", want: http.StatusOK},
+		{name: "second board set", path: "/jobs/done/download/2", want: http.StatusOK, disposition: `attachment; filename="Club _ Pairs-2.pbn"`},
+		{name: "board set out of range", path: "/jobs/done/download/3", want: http.StatusNotFound},
+		{name: "download while processing", path: "/jobs/running/download/1", want: http.StatusAccepted},
+		{name: "board set as csv", path: "/jobs/done/download/1?format=csv", want: http.StatusOK, disposition: `attachment; filename="Club _ Pairs-1.csv"`},
+		{name: "unknown format", path: "/jobs/done/download/1?format=doc", want: http.StatusBadRequest},
+		{name: "web ui", path: "/", want: http.StatusOK},
+		{name: "web ui script", path: "/static/app.js", want: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if w.Code != tt.want {
+				t.Fatalf("GET %s status = %d, want %d (%s)", tt.path, w.Code, tt.want, w.Body.String())
+			}
+			if got := w.Header().Get("Content-Disposition"); got != tt.disposition {
+				t.Errorf("GET %s Content-Disposition = %q, want %q", tt.path, got, tt.disposition)
+			}
+		})
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/jobs/done", nil))
+	var job JobResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &job); err != nil {
+		t.Fatalf("GET /jobs/done body = %s: %v", w.Body.String(), err)
+	}
+	if job.Status != "done" || len(job.Downloads) != 2 || job.Downloads[1] != "/jobs/done/download/2" {
+		t.Errorf("GET /jobs/done = %+v", job)
+	}
+}
+
+func TestExtractionController_GetJobEvents(t *testing.T) {
+	release := make(chan struct{})
+	tournament := newTestTournament(t)
+	gated := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/settings.json" {
+			<-release
+		}
+		resp, err := http.Get(tournament.URL + r.URL.Path)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		defer resp.Body.Close()
+		w.WriteHeader(resp.StatusCode)
+		_, _ = io.Copy(w, resp.Body)
+	}))
+	defer gated.Close()
+	api := httptest.NewServer(newTestRouter(newTestCache(t)))
+	defer api.Close()
+
+	resp, err := http.Post(api.URL+"/jobs", "application/json", strings.NewReader(`{"baseUrl":"`+gated.URL+`/"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var job JobResponse
+	_ = json.NewDecoder(resp.Body).Decode(&job)
+	resp.Body.Close()
+
+	resp, err = http.Get(api.URL + "/jobs/" + job.Id + "/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if got := resp.Header.Get("Content-Type"); got != "text/event-stream" {
+		t.Fatalf("GET /jobs/:id/events Content-Type = %q", got)
+	}
+	close(release)
+
+	events := map[string]int{}
+	var done string
+	scanner := bufio.NewScanner(resp.Body)
+	var event string
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "event:") {
+			event = line[len("event:"):]
+			events[event]++
+		}
+		if strings.HasPrefix(line, "data:") && event == EventDone {
+			done = line[len("data:"):]
+		}
+	}
+	if events[EventBoardFetched] != 1 || events[EventBoardFailed] != 1 || events[EventBoardSerialized] != 1 {
+		t.Errorf("GET /jobs/:id/events events = %v, want a fetched, failed and serialized board", events)
+	}
+	if !strings.Contains(done, `"/jobs/`+job.Id+`/download/1"`) {
+		t.Errorf("GET /jobs/:id/events done = %s, want download link", done)
+	}
+}
+
+func TestExtractionController_FillMissing(t *testing.T) {
+	server := newTestTournament(t)
+	cache := newTestCache(t)
+	es := NewExtractionService(extractor.NewExtractor("test", time.Second).WithRetries(0), cache)
+	result := es.extract(context.Background(), data.Options{BaseUrl: server.URL + "/", FillMissing: true}, func(JobEvent) {})
+	if sets := len(result.BoardSets); sets != 1 || strings.Count(result.BoardSets[0], "[Board ") != 2 {
+		t.Fatalf("extract() board sets = %q, want one set with the placeholder of board 2", result.BoardSets)
+	}
+	_ = cache.SaveResult("job", *result)
+	router := newTestRouter(cache)
+
+	tests := []struct {
+		name string
+		path string
+		want int
+		// lines is how many lines the body has, 0 to skip the check
+		lines int
+	}{
+		{name: "boards", path: "/jobs/job/boards", want: http.StatusOK},
+		{name: "diagram", path: "/jobs/job/boards/1.svg", want: http.StatusOK},
+		{name: "placeholder diagram", path: "/jobs/job/boards/2.svg", want: http.StatusNotFound},
+		{name: "csv", path: "/jobs/job/download/1?format=csv", want: http.StatusOK, lines: 2},
+		{name: "pbn", path: "/jobs/job/download/1", want: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if w.Code != tt.want {
+				t.Fatalf("GET %s status = %d, want %d (%s)", tt.path, w.Code, tt.want, w.Body.String())
+			}
+			if lines := strings.Count(w.Body.String(), "\n"); tt.lines != 0 && lines != tt.lines {
+				t.Errorf("GET %s = %d lines, want %d without the placeholder", tt.path, lines, tt.lines)
+			}
+		})
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/jobs/job/boards", nil))
+	var document export.Document
+	if err := json.Unmarshal(w.Body.Bytes(), &document); err != nil {
+		t.Fatal(err)
+	}
+	if len(document.Boards) != 1 || document.Boards[0].Board != 1 || document.Boards[0].Hands.North.Spades != "AK5" {
+		t.Errorf("GET /jobs/job/boards = %+v, want board 1 only", document.Boards)
+	}
+}
+
+func TestExtractionController_GetJobEventsClosedWhileProcessing(t *testing.T) {
+	cache := newTestCache(t)
+	_, _ = cache.Claim("running", "other", data.Options{})
+	es := NewExtractionService(nil, cache)
+	api := httptest.NewServer(NewApp(NewExtractionController(es), "").Router())
+	defer api.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	request, _ := http.NewRequestWithContext(ctx, http.MethodGet, api.URL+"/jobs/running/events", nil)
+	resp, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	for subscribed := false; !subscribed; {
+		es.events.mu.Lock()
+		subscribed = len(es.events.subscribers["running"]) > 0
+		es.events.mu.Unlock()
+	}
+	// a worker that lost its claim finishes its events while the job is still processing elsewhere
+	es.events.finish("running")
+
+	var progress int
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		if scanner.Text() == "event:"+EventProgress {
+			progress++
+		}
+	}
+	if progress > 2 {
+		t.Errorf("GET /jobs/:id/events sent %d progress events after the channel closed, want the ticker to pace them", progress)
+	}
+}
+
+func TestExtractionController_GetJobEventsNotFound(t *testing.T) {
+	es := NewExtractionService(nil, newTestCache(t))
+	router := NewApp(NewExtractionController(es), "").Router()
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/jobs/missing/events", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("GET /jobs/missing/events = %d, want %d", w.Code, http.StatusNotFound)
+	}
+	if len(es.events.subscribers) != 0 {
+		t.Errorf("subscribers after a missing job = %v, want none", es.events.subscribers)
+	}
+}
+
+func TestExtractionController_CancelJob(t *testing.T) {
+	cache := newTestCache(t)
+	_ = cache.SaveResult("done", *data.NewResult())
+	_ = cache.SaveCancelled("cancelled", *data.NewResult())
+	_, _ = cache.Claim("running", "other", data.Options{})
+	router := newTestRouter(cache)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		want   int
+		status string
+	}{
+		{name: "unknown job", method: http.MethodDelete, path: "/jobs/missing", want: http.StatusNotFound},
+		{name: "finished job", method: http.MethodDelete, path: "/jobs/done", want: http.StatusConflict},
+		{name: "running job", method: http.MethodDelete, path: "/jobs/running", want: http.StatusAccepted, status: "processing"},
+		{name: "cancelled job", method: http.MethodGet, path: "/jobs/cancelled", want: http.StatusOK, status: "cancelled"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+			if w.Code != tt.want {
+				t.Fatalf("%s %s status = %d, want %d (%s)", tt.method, tt.path, w.Code, tt.want, w.Body.String())
+			}
+			var job JobResponse
+			_ = json.Unmarshal(w.Body.Bytes(), &job)
+			if job.Status != tt.status {
+				t.Errorf("%s %s job status = %q, want %q", tt.method, tt.path, job.Status, tt.status)
+			}
+		})
+	}
+	if requested, _ := cache.CancelRequested("running"); !requested {
+		t.Errorf("DELETE /jobs/running did not request cancellation")
+	}
+}
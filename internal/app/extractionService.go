@@ -2,111 +2,572 @@ package app
 
 import (
 	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/fe-dox/go-pbn"
 	"github.com/fe-dox/tc-pbn-extractor/internal/data"
+	"github.com/fe-dox/tc-pbn-extractor/internal/export"
 	"github.com/fe-dox/tc-pbn-extractor/internal/extractor"
+	"github.com/fe-dox/tc-pbn-extractor/internal/selection"
+	"golang.org/x/sync/singleflight"
 	"log"
 	"net/url"
-	"strconv"
-	"strings"
+	"sync"
 	"time"
 )
 
 type ExtractionService struct {
-	ex *extractor.Extractor
-	pc data.ResultsCache
+	ex      *extractor.Extractor
+	pc      data.ResultsCache
+	events  *eventBroker
+	queue   *singleflight.Group
+	jobs    data.JobQueue
+	running *runningJobs
+	// cancelPoll is how often running jobs check whether they were cancelled from another process
+	cancelPoll time.Duration
+	heartbeat  time.Duration
 }
 
+func NewExtractionService(ex *extractor.Extractor, pc data.ResultsCache) ExtractionService {
+	return ExtractionService{
+		ex:         ex,
+		pc:         pc,
+		events:     newEventBroker(),
+		queue:      &singleflight.Group{},
+		running:    &runningJobs{cancels: make(map[string]context.CancelFunc)},
+		cancelPoll: time.Second,
+		heartbeat:  data.DefaultProcessingTTL / 3,
+	}
+}
+
+// runningJobs cancels jobs extracted by this process without waiting for them to poll the results cache.
+type runningJobs struct {
+	mu      sync.Mutex
+	cancels map[string]context.CancelFunc
+}
+
+func (r *runningJobs) add(jobHash string, cancel context.CancelFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cancels[jobHash] = cancel
+}
+
+func (r *runningJobs) remove(jobHash string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.cancels, jobHash)
+}
+
+func (r *runningJobs) cancel(jobHash string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if cancel, ok := r.cancels[jobHash]; ok {
+		cancel()
+	}
+}
+
+// WithHeartbeat sets how often running jobs refresh their claim, it has to be well below the processing TTL of
+// the results cache.
+func (es ExtractionService) WithHeartbeat(interval time.Duration) ExtractionService {
+	es.heartbeat = interval
+	return es
+}
+
+// WithQueue makes QueueJob hand jobs over to workers through q instead of extracting them in this process, see
+// Work.
+func (es ExtractionService) WithQueue(q data.JobQueue) ExtractionService {
+	es.jobs = q
+	return es
+}
+
+// MaxJobAttempts is how many times a job is claimed before RecoverAbandoned gives up on it.
+const MaxJobAttempts = 3
+
+// MaxJobDeliveries is how many times workers try a job that fails before its failed result is saved.
+const MaxJobDeliveries = 3
+
 var (
 	ErrJobIsStillBeingProcessed = errors.New("job is still being processed")
 	ErrJobAlreadyProcessing     = errors.New("job is already being processed")
 	ErrJobNotFound              = errors.New("job not found")
+	ErrHistoryUnsupported       = errors.New("results cache does not keep history")
+	ErrJobAbandoned             = errors.New("job was abandoned by its worker too many times")
+	ErrJobNotRunning            = errors.New("job is not running")
+	ErrJobCancelled             = errors.New("job was cancelled")
 )
 
+// QueueJob starts extraction of a job unless it is processing or done already. Requests for the same job in
+// this process are collapsed into one, and the job is claimed in the results cache, so only one process ever
+// extracts it.
 func (es ExtractionService) QueueJob(options data.Options) (string, error) {
+	err := validateOptions(options)
+	if err != nil {
+		return "", err
+	}
 	jobHash := options.Hash()
+	_, err, _ = es.queue.Do(jobHash, func() (interface{}, error) {
+		return nil, es.claimAndRun(jobHash, options)
+	})
+	if err != nil && !errors.Is(err, ErrJobAlreadyProcessing) {
+		return "", err
+	}
+	return jobHash, err
+}
+
+func (es ExtractionService) claimAndRun(jobHash string, options data.Options) error {
 	status, err := es.pc.GetStatus(jobHash)
 	if err != nil {
-		return "", err
+		return err
 	}
-	if status == data.JobProcessing {
-		return "", ErrJobAlreadyProcessing
+	if status == data.JobProcessing || status == data.JobQueued {
+		return ErrJobAlreadyProcessing
 	}
-	if status == data.JobDone && !options.ForceRefresh {
-		return jobHash, nil
+	if (status == data.JobDone || status == data.JobCancelled) && !options.ForceRefresh {
+		return nil
 	}
-	err = es.pc.SetStatusProcessing(jobHash)
+	if es.jobs != nil {
+		return es.enqueue(jobHash, options)
+	}
+	owner, err := newOwnerToken()
 	if err != nil {
-		return "", err
+		return err
 	}
-	go func() {
-		result := es.Extract(options)
-		err := es.pc.SaveResult(jobHash, *result)
+	claimed, err := es.pc.Claim(jobHash, owner, options)
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		return ErrJobAlreadyProcessing
+	}
+	go es.run(jobHash, owner, options)
+	return nil
+}
+
+// enqueue marks a job queued and hands it to the queue set with WithQueue, it is claimed by the worker that takes
+// it. A job that can't be enqueued is taken and released right away, which leaves it abandoned, so recovery
+// queues it again once the queue is back.
+func (es ExtractionService) enqueue(jobHash string, options data.Options) error {
+	queued, err := es.pc.Queue(jobHash, options)
+	if err != nil {
+		return err
+	}
+	if !queued {
+		return ErrJobAlreadyProcessing
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*1)
+	defer cancel()
+	err = es.jobs.Enqueue(ctx, data.QueuedJob{Hash: jobHash, Options: options})
+	if err != nil {
+		if owner, tokenErr := newOwnerToken(); tokenErr == nil {
+			if taken, _ := es.pc.Take(jobHash, owner, options); taken {
+				_ = es.pc.Release(jobHash, owner)
+			}
+		}
+		return err
+	}
+	return nil
+}
+
+// run extracts a claimed job and saves its result.
+func (es ExtractionService) run(jobHash string, owner string, options data.Options) {
+	result, cancelled := es.extractClaimed(jobHash, owner, options)
+	es.finish(jobHash, owner, result, cancelled)
+}
+
+// extractClaimed extracts a job, keeping its claim alive meanwhile, and reports whether it was cancelled.
+func (es ExtractionService) extractClaimed(jobHash string, owner string, options data.Options) (*data.Result, bool) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	es.running.add(jobHash, cancel)
+	defer es.running.remove(jobHash)
+	if requested, _ := es.pc.CancelRequested(jobHash); requested {
+		cancel()
+	}
+	stopHeartbeat := es.keepClaim(jobHash, owner, cancel)
+	defer stopHeartbeat()
+	result := es.extract(ctx, options, func(event JobEvent) {
+		if event.Type == EventProgress {
+			err := es.pc.SaveProgress(jobHash, *event.Progress)
+			if err != nil {
+				log.Printf("Job %s progress save failed: %v", jobHash, err)
+			}
+		}
+		es.events.publish(jobHash, event)
+	})
+	return result, ctx.Err() != nil
+}
+
+// finish saves the result of a job, as cancelled if it was, and releases its claim. A job whose claim was lost
+// saves nothing, the result belongs to whoever holds the claim now.
+func (es ExtractionService) finish(jobHash string, owner string, result *data.Result, cancelled bool) {
+	defer es.events.finish(jobHash)
+	err := es.pc.Refresh(jobHash, owner)
+	if errors.Is(err, data.ErrClaimLost) {
+		log.Printf("Job %s lost its claim, its result is not saved", jobHash)
+		return
+	}
+	if err != nil {
+		log.Printf("Job %s heartbeat failed: %v", jobHash, err)
+	}
+	if cancelled {
+		log.Printf("Job %s was cancelled", jobHash)
+		err = es.pc.SaveCancelled(jobHash, *result)
+	} else {
+		err = es.pc.SaveResult(jobHash, *result)
+	}
+	if err != nil {
+		log.Printf("Job %s db save failed: %v", jobHash, err)
+	}
+	err = es.pc.Release(jobHash, owner)
+	if err != nil {
+		log.Printf("Job %s claim release failed: %v", jobHash, err)
+	}
+}
+
+// Work runs workers extracting jobs from the queue set with WithQueue until ctx is done. Jobs being extracted
+// when ctx is done are still finished.
+func (es ExtractionService) Work(ctx context.Context, workers int) {
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				delivery, err := es.jobs.Receive(ctx)
+				if ctx.Err() != nil {
+					return
+				}
+				if err != nil {
+					log.Printf("Failed to receive a job: %v", err)
+					time.Sleep(time.Second)
+					continue
+				}
+				es.handle(delivery)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// handle claims a delivered job with Take. A delivery of a job that is no longer queued is a duplicate, or a
+// redelivery of a job whose worker is still running it, so it is just dropped. A delivery whose worker vanished
+// before taking the job is still queued, so it is taken once the queue redelivers it.
+func (es ExtractionService) handle(delivery data.Delivery) {
+	job := delivery.Job
+	owner, err := newOwnerToken()
+	if err != nil {
+		log.Printf("Job %s can't be claimed: %v", job.Hash, err)
+		return
+	}
+	taken, err := es.pc.Take(job.Hash, owner, job.Options)
+	if err != nil {
+		log.Printf("Job %s can't be claimed: %v", job.Hash, err)
+		return
+	}
+	if !taken {
+		log.Printf("Job %s is no longer queued, dropping its delivery", job.Hash)
+		es.ack(delivery)
+		return
+	}
+	result, cancelled := es.extractClaimed(job.Hash, owner, job.Options)
+	if !result.Success && !cancelled && job.Attempt+1 < MaxJobDeliveries {
+		es.retry(delivery, owner)
+		return
+	}
+	es.finish(job.Hash, owner, result, cancelled)
+	es.ack(delivery)
+}
+
+// retry queues a failed job again for another delivery. Its claim is released first, so a failure before the job
+// is queued again leaves it abandoned and recovery queues it, and a failed Retry leaves the delivery to be
+// redelivered.
+func (es ExtractionService) retry(delivery data.Delivery, owner string) {
+	job := delivery.Job
+	log.Printf("Job %s failed, retrying it", job.Hash)
+	err := es.pc.Release(job.Hash, owner)
+	if err != nil {
+		log.Printf("Job %s claim release failed: %v", job.Hash, err)
+		es.ack(delivery)
+		return
+	}
+	queued, err := es.pc.Queue(job.Hash, job.Options)
+	if err != nil || !queued {
 		if err != nil {
-			log.Printf("Job %s db save failed: %v", jobHash, err)
+			log.Printf("Job %s can't be queued again: %v", job.Hash, err)
+		}
+		es.ack(delivery)
+		return
+	}
+	err = es.jobs.Retry(context.Background(), delivery)
+	if err != nil {
+		log.Printf("Job %s retry failed: %v", job.Hash, err)
+	}
+}
+
+func (es ExtractionService) ack(delivery data.Delivery) {
+	err := es.jobs.Ack(context.Background(), delivery)
+	if err != nil {
+		log.Printf("Job %s ack failed: %v", delivery.Job.Hash, err)
+	}
+}
+
+// keepClaim refreshes a claim every heartbeat until the returned function is called or the claim is lost. It
+// calls cancel once the job is cancelled in the results cache or the claim is lost.
+func (es ExtractionService) keepClaim(jobHash string, owner string, cancel context.CancelFunc) func() {
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(es.heartbeat)
+		defer ticker.Stop()
+		poll := time.NewTicker(es.cancelPoll)
+		defer poll.Stop()
+		for {
+			select {
+			case <-poll.C:
+				requested, err := es.pc.CancelRequested(jobHash)
+				if err != nil {
+					log.Printf("Job %s cancellation check failed: %v", jobHash, err)
+				}
+				if requested {
+					cancel()
+				}
+			case <-ticker.C:
+				err := es.pc.Refresh(jobHash, owner)
+				if errors.Is(err, data.ErrClaimLost) {
+					log.Printf("Job %s lost its claim, stopping it as another worker may process it", jobHash)
+					cancel()
+					return
+				}
+				if err != nil {
+					log.Printf("Job %s heartbeat failed: %v", jobHash, err)
+				}
+			case <-stop:
+				return
+			}
 		}
 	}()
-	return jobHash, nil
+	return func() {
+		close(stop)
+		<-done
+	}
+}
+
+// RecoverAbandoned queues pending jobs whose owner vanished again. Jobs claimed MaxJobAttempts times already
+// fail with ErrJobAbandoned instead, they are likely what made their workers vanish.
+func (es ExtractionService) RecoverAbandoned() error {
+	abandoned, err := es.pc.Abandoned()
+	if err != nil {
+		return err
+	}
+	for jobHash, pending := range abandoned {
+		if pending.Attempts >= MaxJobAttempts {
+			err = es.failAbandoned(jobHash, pending.Options)
+		} else {
+			log.Printf("Job %s was abandoned, queueing it again", jobHash)
+			_, err, _ = es.queue.Do(jobHash, func() (interface{}, error) {
+				return nil, es.claimAndRun(jobHash, pending.Options)
+			})
+		}
+		if err != nil && !errors.Is(err, ErrJobAlreadyProcessing) {
+			return err
+		}
+	}
+	return nil
+}
+
+// failAbandoned saves a failed result, the job is claimed first so a worker that picked it up meanwhile wins.
+func (es ExtractionService) failAbandoned(jobHash string, options data.Options) error {
+	owner, err := newOwnerToken()
+	if err != nil {
+		return err
+	}
+	claimed, err := es.pc.Claim(jobHash, owner, options)
+	if err != nil || !claimed {
+		return err
+	}
+	log.Printf("Job %s was abandoned %d times, giving up", jobHash, MaxJobAttempts)
+	result := data.NewResult().WithError(ErrJobAbandoned)
+	result.BaseUrl = options.BaseUrl
+	err = es.pc.SaveResult(jobHash, *result)
+	if err != nil {
+		return err
+	}
+	return es.pc.Release(jobHash, owner)
+}
+
+// RecoverPeriodically runs RecoverAbandoned every interval for the lifetime of the process.
+func (es ExtractionService) RecoverPeriodically(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		err := es.RecoverAbandoned()
+		if err != nil {
+			log.Printf("Failed to recover abandoned jobs: %v", err)
+		}
+	}
+}
+
+// newOwnerToken identifies a single claim of a job, so a process never releases a claim it lost to another one.
+func newOwnerToken() (string, error) {
+	token := make([]byte, 16)
+	_, err := rand.Read(token)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(token), nil
 }
 
 func (es ExtractionService) GetJob(jobHash string) (data.Result, error) {
+	_, result, err := es.GetJobWithStatus(jobHash)
+	return result, err
+}
+
+// GetJobWithStatus is GetJob that also tells finished jobs from cancelled ones.
+func (es ExtractionService) GetJobWithStatus(jobHash string) (data.JobStatus, data.Result, error) {
 	status, result, err := es.pc.Get(jobHash)
 	if err != nil {
-		return data.Result{}, err
+		return status, data.Result{}, err
 	}
 	if status == data.JobNotFound {
-		return data.Result{}, ErrJobNotFound
+		return status, data.Result{}, ErrJobNotFound
 	}
-	if status == data.JobProcessing {
-		return data.Result{}, ErrJobIsStillBeingProcessed
+	if status == data.JobProcessing || status == data.JobQueued {
+		return status, data.Result{}, ErrJobIsStillBeingProcessed
 	}
-	return result, nil
+	return status, result, nil
+}
+
+// CancelJob stops a processing job wherever it runs, boards extracted so far are kept as its result. Jobs in
+// this process stop right away, others once they poll the results cache.
+func (es ExtractionService) CancelJob(jobHash string) error {
+	err := es.pc.Cancel(jobHash)
+	if errors.Is(err, data.ErrNotClaimed) {
+		status, statusErr := es.pc.GetStatus(jobHash)
+		if statusErr != nil {
+			return statusErr
+		}
+		if status == data.JobNotFound {
+			return ErrJobNotFound
+		}
+		return ErrJobNotRunning
+	}
+	if err != nil {
+		return err
+	}
+	es.running.cancel(jobHash)
+	return nil
+}
+
+// GetProgress returns progress of a job, it is zero when a job hasn't reported any yet.
+func (es ExtractionService) GetProgress(jobHash string) (data.Progress, error) {
+	return es.pc.GetProgress(jobHash)
+}
+
+// Recent lists finished jobs newest first when the results cache keeps history.
+func (es ExtractionService) Recent(baseUrl string, limit int) ([]data.HistoryEntry, error) {
+	history, ok := es.pc.(data.ResultsHistory)
+	if !ok {
+		return nil, ErrHistoryUnsupported
+	}
+	return history.Recent(baseUrl, limit)
+}
+
+// Subscribe listens to events of a job running in this process. The channel is closed once the job result is
+// saved, there are no events for jobs that already finished.
+func (es ExtractionService) Subscribe(jobHash string) (<-chan JobEvent, func()) {
+	return es.events.subscribe(jobHash)
 }
 
 func (es ExtractionService) Extract(options data.Options) *data.Result {
+	return es.extract(context.Background(), options, func(JobEvent) {})
+}
+
+// extract does the work of Extract, reporting progress before and after every board is downloaded along with
+// events of every board. Once ctx is done no more boards are downloaded, those extracted until then make the
+// result along with ErrJobCancelled.
+func (es ExtractionService) extract(ctx context.Context, options data.Options, observe func(JobEvent)) *data.Result {
+	progress := data.Progress{Started: time.Now()}
+	report := func() {
+		p := progress
+		observe(JobEvent{Type: EventProgress, Progress: &p})
+	}
+	report()
+	result := data.NewResult()
+	result.BaseUrl = options.BaseUrl
 	if _, err := url.Parse(options.BaseUrl); err != nil {
-		return data.NewResult().WithError(ErrInvalidBaseUrl)
+		return result.WithError(ErrInvalidBaseUrl)
+	}
+	ex := es.ex.WithContext(ctx)
+	settings, err := ex.ExtractSettingsFromUrl(options.BaseUrl)
+	if ctx.Err() != nil {
+		return result.WithError(ErrJobCancelled)
 	}
-	settings, err := es.ex.ExtractSettingsFromUrl(options.BaseUrl)
 	if err != nil {
-		return data.NewResult().WithError(err)
+		return result.WithError(err)
 	}
 
 	if options.EventName == "" {
 		options.EventName = settings.EventName
 	}
 
-	boardRanges, err := getBoardsToExtract(options.BoardsRange, settings.StartBoardNumber, settings.EndBoardNumber)
+	sel, err := selection.Parse(options.BoardsRange, settings)
 	if err != nil {
-		return data.NewResult().WithError(err)
+		return result.WithError(err)
 	}
 
 	type extractionResult struct {
-		Board []pbn.Board
-		Err   error
+		Number int
+		Board  []pbn.Board
+		Err    error
 	}
 	ch := make(chan extractionResult, 1)
 
+	progress.Total = len(sel.Boards)
 	go func() {
-		for _, boardRange := range boardRanges {
-			for i := boardRange[0]; i <= boardRange[1]; i++ {
-				board, err := es.ex.ExtractOneFromUrl(options.BaseUrl, i)
-				if err != nil {
-					board = []pbn.Board{{Number: i}}
-				}
-				ch <- extractionResult{
-					Err:   err,
-					Board: board,
-				}
-				time.Sleep(100 * time.Millisecond)
+		for _, i := range sel.Boards {
+			if ctx.Err() != nil {
+				break
+			}
+			progress.CurrentBoard = i
+			report()
+			board, err := ex.ExtractOneFromUrl(options.BaseUrl, i)
+			// a board downloaded before the job was cancelled is kept, a download cut short is not a failure
+			if err != nil && ctx.Err() != nil {
+				break
+			}
+			if err != nil {
+				progress.Failed++
+				observe(JobEvent{Type: EventBoardFailed, Board: i, Error: err.Error()})
+				board = []pbn.Board{{Number: i}}
+			} else {
+				progress.Fetched++
+				observe(JobEvent{Type: EventBoardFetched, Board: i})
+				board = sel.Filter(board)
+			}
+			progress.UpdateEta(time.Now())
+			if len(board) == 0 {
+				continue
+			}
+			ch <- extractionResult{
+				Number: i,
+				Err:    err,
+				Board:  board,
+			}
+			select {
+			case <-time.After(100 * time.Millisecond):
+			case <-ctx.Done():
 			}
 		}
+		progress.CurrentBoard = 0
+		report()
 		close(ch)
 	}()
 
-	result := data.NewResult()
 	result.EventName = options.EventName
 
 	var prevBoardNumber int
@@ -115,7 +576,7 @@ func (es ExtractionService) Extract(options data.Options) *data.Result {
 
 	for boardResults := range ch {
 		if boardResults.Err != nil {
-			result.AddError(fmt.Errorf("Failed to extract Board %d: %v\n", boardResults.Board[0].Number, boardResults.Err))
+			result.AddError(fmt.Errorf("failed to extract board %d: %w", boardResults.Board[0].Number, boardResults.Err))
 			if !options.FillMissing {
 				continue
 			}
@@ -132,79 +593,46 @@ func (es ExtractionService) Extract(options data.Options) *data.Result {
 			board.Generator = data.GENERATOR
 			err = board.Serialize(b, true)
 			if err != nil {
-				result.AddError(fmt.Errorf("Failed to serialize Board %d (number as played): %v\n", board.Number, err))
+				err = fmt.Errorf("failed to serialize board %d (number as played): %w", board.Number, err)
+				result.AddError(err)
+				observe(JobEvent{Type: EventBoardFailed, Board: boardResults.Number, Error: err.Error()})
+				continue
+			}
+			observe(JobEvent{Type: EventBoardSerialized, Board: board.Number})
+			if boardResults.Err != nil {
+				continue
+			}
+			encoded, err := json.Marshal(export.NewBoard(board, export.Source{Url: options.BaseUrl, Board: boardResults.Number}))
+			if err != nil {
+				result.AddError(fmt.Errorf("failed to encode board %d (number as played): %w", board.Number, err))
 				continue
 			}
+			result.AddBoard(encoded)
 		}
 	}
 	result.Success = true
 	result.AddBoardSet(b.String())
+	if ctx.Err() != nil {
+		result.AddError(ErrJobCancelled)
+	}
 	return result
 }
 
 var (
-	ErrInvalidBaseUrl                       = errors.New("invalid base URL")
-	ErrInvalidBoardsRange                   = errors.New("invalid boards range")
-	ErrSelectedBoardsDoNotExistInTournament = errors.New("selected boards do not exist in tournament")
-	ErrBoardsNotInOrder                     = errors.New("selected boards are not in order")
+	ErrInvalidBaseUrl = errors.New("invalid base URL")
+	ErrInvalidOptions = errors.New("invalid options")
 )
 
-func getBoardsToExtract(str string, start int, end int) ([][2]int, error) {
-	if str == "" {
-		return [][2]int{{start, end}}, nil
-	}
-	boards := make([][2]int, 0, 1)
-	ranges := strings.Split(str, ",")
-	var currentHighest int
-	if len(ranges) == 0 {
-		return nil, ErrInvalidBoardsRange
-	}
-	for _, r := range ranges {
-		if strings.Contains(r, "-") {
-			parts := strings.Split(r, "-")
-			if len(parts) != 2 {
-				return nil, ErrInvalidBoardsRange
-			}
-			from, err := strconv.Atoi(parts[0])
-			if err != nil {
-				return nil, ErrInvalidBoardsRange
-			}
-			if from <= currentHighest {
-				return nil, ErrBoardsNotInOrder
-			}
-			currentHighest = from
-			if from < start {
-				return nil, ErrSelectedBoardsDoNotExistInTournament
-			}
-			to, err := strconv.Atoi(parts[1])
-			if err != nil {
-				return nil, ErrInvalidBoardsRange
-			}
-			if to <= currentHighest {
-				return nil, ErrBoardsNotInOrder
-			}
-			currentHighest = to
-			if to > end {
-				return nil, ErrSelectedBoardsDoNotExistInTournament
-			}
-			if from > to {
-				return nil, ErrBoardsNotInOrder
-			}
-			boards = append(boards, [2]int{from, to})
-		} else {
-			board, err := strconv.Atoi(r)
-			if err != nil {
-				return nil, ErrInvalidBoardsRange
-			}
-			if board <= currentHighest {
-				return nil, ErrBoardsNotInOrder
-			}
-			if board < start || board > end {
-				return nil, ErrSelectedBoardsDoNotExistInTournament
-			}
-			currentHighest = board
-			boards = append(boards, [2]int{board, board})
-		}
+// validateOptions rejects jobs that can't succeed before anything is queued. Board selection is only checked for
+// syntax, whether boards exist is known once settings are downloaded.
+func validateOptions(options data.Options) error {
+	u, err := url.Parse(options.BaseUrl)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: %v", ErrInvalidOptions, ErrInvalidBaseUrl)
+	}
+	err = selection.Validate(options.BoardsRange)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidOptions, err)
 	}
-	return boards, nil
+	return nil
 }
@@ -0,0 +1,434 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/fe-dox/tc-pbn-extractor/internal/data"
+	"github.com/fe-dox/tc-pbn-extractor/internal/extractor"
+	"github.com/fe-dox/tc-pbn-extractor/internal/memory"
+	"github.com/fe-dox/tc-pbn-extractor/internal/redis"
+)
+
+const testProtocol = `{"ScoringGroups": [{"Distribution": {"Number": 1, "_numberAsPlayed": 1, "_handRecord": {"Dealer": 0,
+"HandN": {"Spades": "AK5", "Hearts": "Q72", "Diamonds": "A984", "Clubs": "J63"},
+"HandE": {"Spades": "QJ10", "Hearts": "AK8", "Diamonds": "765", "Clubs": "K982"},
+"HandS": {"Spades": "9876", "Hearts": "J109", "Diamonds": "KQ", "Clubs": "AQ104"},
+"HandW": {"Spades": "432", "Hearts": "6543", "Diamonds": "J1032", "Clubs": "75"},
+"Vulnerability": 0}}, "Results": []}]}`
+
+// newTestTournament serves a two board tournament where the protocol of board 2 is missing.
+func newTestTournament(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/settings.json", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"BoardsNumbers": [1, 2], "FullName": "Test Pairs"}`))
+	})
+	mux.HandleFunc("/p1.json", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(testProtocol))
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestExtractionService_extractReportsProgress(t *testing.T) {
+	server := newTestTournament(t)
+	es := NewExtractionService(extractor.NewExtractor("test", time.Second), newTestCache(t))
+
+	var reports []data.Progress
+	result := es.extract(context.Background(), data.Options{BaseUrl: server.URL + "/"}, func(event JobEvent) {
+		if event.Type == EventProgress {
+			reports = append(reports, *event.Progress)
+		}
+	})
+	if len(result.Boards) != 1 || len(result.Errors) != 1 {
+		t.Fatalf("extract() boards = %d, errors = %v, want 1 board and 1 error", len(result.Boards), result.Errors)
+	}
+	last := reports[len(reports)-1]
+	if last.Total != 2 || last.Fetched != 1 || last.Failed != 1 || last.CurrentBoard != 0 {
+		t.Errorf("extract() final progress = %+v, want 2 total, 1 fetched, 1 failed", last)
+	}
+	if last.Eta == nil || last.Started.IsZero() {
+		t.Errorf("extract() final progress = %+v, want start time and ETA", last)
+	}
+	var sawCurrent bool
+	for _, progress := range reports {
+		sawCurrent = sawCurrent || progress.CurrentBoard == 2
+	}
+	if !sawCurrent {
+		t.Errorf("extract() never reported board 2 as current")
+	}
+}
+
+// cancellingTransport cancels a job once a protocol is downloaded, before the extractor gets it.
+type cancellingTransport struct {
+	next   http.RoundTripper
+	cancel context.CancelFunc
+}
+
+func (c cancellingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	resp, err := c.next.RoundTrip(r)
+	if err != nil || r.URL.Path != "/p1.json" {
+		return resp, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	c.cancel()
+	return resp, nil
+}
+
+func TestExtractionService_extractKeepsBoardDownloadedBeforeCancel(t *testing.T) {
+	server := newTestTournament(t)
+	es := NewExtractionService(extractor.NewExtractor("test", time.Second), newTestCache(t))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	transport := http.DefaultTransport
+	http.DefaultTransport = cancellingTransport{next: transport, cancel: cancel}
+	defer func() {
+		http.DefaultTransport = transport
+	}()
+
+	result := es.extract(ctx, data.Options{BaseUrl: server.URL + "/"}, func(JobEvent) {})
+	if len(result.Boards) != 1 {
+		t.Errorf("extract() boards = %d, want the board downloaded before the cancellation", len(result.Boards))
+	}
+	if len(result.Errors) != 1 || result.Errors[0] != ErrJobCancelled.Error() {
+		t.Errorf("extract() errors = %v, want only %v", result.Errors, ErrJobCancelled)
+	}
+}
+
+func TestExtractionService_QueueJobClaimsOnce(t *testing.T) {
+	var mu sync.Mutex
+	var settingsRequests int
+	tournament := newTestTournament(t)
+	counting := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/settings.json" {
+			mu.Lock()
+			settingsRequests++
+			mu.Unlock()
+		}
+		http.Redirect(w, r, tournament.URL+r.URL.Path, http.StatusTemporaryRedirect)
+	}))
+	defer counting.Close()
+
+	// two services sharing a cache stand for two API processes
+	cache := newTestCache(t)
+	services := []ExtractionService{
+		NewExtractionService(extractor.NewExtractor("test", time.Second), cache),
+		NewExtractionService(extractor.NewExtractor("test", time.Second), cache),
+	}
+	options := data.Options{BaseUrl: counting.URL + "/"}
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(es ExtractionService) {
+			defer wg.Done()
+			id, err := es.QueueJob(options)
+			if id != options.Hash() || (err != nil && !errors.Is(err, ErrJobAlreadyProcessing)) {
+				t.Errorf("QueueJob() = %s, %v", id, err)
+			}
+		}(services[i%2])
+	}
+	wg.Wait()
+
+	waitForStatus(t, cache, options.Hash(), data.JobDone)
+	if settingsRequests != 1 {
+		t.Errorf("settings downloaded %d times, want once", settingsRequests)
+	}
+}
+
+// waitForStatus polls the cache until a job reaches status or fails the test after a few seconds.
+func waitForStatus(t *testing.T, cache data.ResultsCache, jobHash string, want data.JobStatus) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		status, _ := cache.GetStatus(jobHash)
+		if status == want {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job status = %v, want %v", status, want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestExtractionService_HeartbeatKeepsClaim(t *testing.T) {
+	tournament := newTestTournament(t)
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(300 * time.Millisecond)
+		http.Redirect(w, r, tournament.URL+r.URL.Path, http.StatusTemporaryRedirect)
+	}))
+	defer slow.Close()
+	cache := memory.NewResultsCache(0, 100*time.Millisecond, 0)
+	defer cache.Close()
+	es := NewExtractionService(extractor.NewExtractor("test", time.Second), cache).WithHeartbeat(20 * time.Millisecond)
+
+	options := data.Options{BaseUrl: slow.URL + "/"}
+	_, err := es.QueueJob(options)
+	if err != nil {
+		t.Fatalf("QueueJob() error = %v", err)
+	}
+	time.Sleep(250 * time.Millisecond)
+	if claimed, _ := cache.Claim(options.Hash(), "other", options); claimed {
+		t.Fatalf("Claim() of a running job succeeded, its heartbeat did not keep the claim")
+	}
+	waitForStatus(t, cache, options.Hash(), data.JobDone)
+}
+
+func TestExtractionService_ClaimLostMidRun(t *testing.T) {
+	tournament := newTestTournament(t)
+	var mu sync.Mutex
+	var protocolRequests int
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/settings.json" {
+			mu.Lock()
+			protocolRequests++
+			mu.Unlock()
+			time.Sleep(300 * time.Millisecond)
+		}
+		http.Redirect(w, r, tournament.URL+r.URL.Path, http.StatusTemporaryRedirect)
+	}))
+	defer slow.Close()
+	// the heartbeat comes after the claim expires, so another worker takes the job over meanwhile
+	cache := memory.NewResultsCache(0, 100*time.Millisecond, 0)
+	defer cache.Close()
+	es := NewExtractionService(extractor.NewExtractor("test", time.Second), cache).WithHeartbeat(200 * time.Millisecond)
+
+	options := data.Options{BaseUrl: slow.URL + "/"}
+	events, unsubscribe := es.Subscribe(options.Hash())
+	defer unsubscribe()
+	_, err := es.QueueJob(options)
+	if err != nil {
+		t.Fatalf("QueueJob() error = %v", err)
+	}
+	time.Sleep(150 * time.Millisecond)
+	if claimed, _ := cache.Claim(options.Hash(), "other", options); !claimed {
+		t.Fatalf("Claim() of an expired claim failed")
+	}
+	_ = cache.SaveResult(options.Hash(), data.Result{Success: true, EventName: "Other"})
+	_ = cache.Release(options.Hash(), "other")
+	for range events {
+	}
+
+	result, err := es.GetJob(options.Hash())
+	if err != nil || result.EventName != "Other" {
+		t.Errorf("GetJob() = %+v, %v, want the result of the new owner kept", result, err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if protocolRequests != 1 {
+		t.Errorf("protocols downloaded %d times, want the run stopped after losing its claim", protocolRequests)
+	}
+}
+
+func TestExtractionService_RecoverAbandoned(t *testing.T) {
+	server := newTestTournament(t)
+	cache := memory.NewResultsCache(0, 20*time.Millisecond, 0)
+	defer cache.Close()
+	es := NewExtractionService(extractor.NewExtractor("test", time.Second), cache).WithHeartbeat(5 * time.Millisecond)
+
+	requeued := data.Options{BaseUrl: server.URL + "/"}
+	failed := data.Options{BaseUrl: server.URL + "/", FillMissing: true}
+	_, _ = cache.Claim(requeued.Hash(), "vanished", requeued)
+	for i := 0; i < MaxJobAttempts; i++ {
+		_, _ = cache.Claim(failed.Hash(), "vanished", failed)
+		time.Sleep(30 * time.Millisecond)
+	}
+
+	err := es.RecoverAbandoned()
+	if err != nil {
+		t.Fatalf("RecoverAbandoned() error = %v", err)
+	}
+	waitForStatus(t, cache, requeued.Hash(), data.JobDone)
+	result, _ := es.GetJob(requeued.Hash())
+	if len(result.Boards) != 1 {
+		t.Errorf("requeued job boards = %d, want 1", len(result.Boards))
+	}
+	result, _ = es.GetJob(failed.Hash())
+	if len(result.Errors) != 1 || result.Errors[0] != ErrJobAbandoned.Error() {
+		t.Errorf("abandoned job errors = %v, want %v", result.Errors, ErrJobAbandoned)
+	}
+}
+
+func TestExtractionService_Work(t *testing.T) {
+	tournament := newTestTournament(t)
+	var mu sync.Mutex
+	var missingRequests int
+	missing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		missingRequests++
+		mu.Unlock()
+		http.NotFound(w, r)
+	}))
+	defer missing.Close()
+	cache := newTestCache(t)
+	q := memory.NewJobQueue()
+	es := NewExtractionService(extractor.NewExtractor("test", time.Second).WithRetries(0), cache).WithQueue(q)
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		es.Work(ctx, 2)
+		close(stopped)
+	}()
+	defer func() {
+		cancel()
+		<-stopped
+	}()
+
+	options := data.Options{BaseUrl: tournament.URL + "/"}
+	failing := data.Options{BaseUrl: missing.URL + "/"}
+	for _, o := range []data.Options{options, failing} {
+		if _, err := es.QueueJob(o); err != nil {
+			t.Fatalf("QueueJob() error = %v", err)
+		}
+	}
+	waitForStatus(t, cache, options.Hash(), data.JobDone)
+	waitForStatus(t, cache, failing.Hash(), data.JobDone)
+	if missingRequests != MaxJobDeliveries {
+		t.Errorf("failing job tried %d times, want %d", missingRequests, MaxJobDeliveries)
+	}
+
+	// a delivery of a job that is no longer queued, like a duplicate of a finished job, is dropped
+	es.handle(data.Delivery{Id: "duplicate", Job: data.QueuedJob{Hash: options.Hash(), Options: failing}})
+	result, err := es.GetJob(options.Hash())
+	if err != nil || len(result.Boards) != 1 {
+		t.Errorf("GetJob() after a duplicate delivery = %d boards, %v, want the result kept", len(result.Boards), err)
+	}
+	if missingRequests != MaxJobDeliveries {
+		t.Errorf("duplicate delivery was extracted")
+	}
+}
+
+func TestExtractionService_QueuedLongerThanProcessingTTL(t *testing.T) {
+	server := newTestTournament(t)
+	mr := miniredis.RunT(t)
+	cache := memory.NewResultsCache(0, 20*time.Millisecond, 0)
+	defer cache.Close()
+	newQueue := func(consumer string) *redis.JobQueue {
+		q, err := redis.NewJobQueue("redis://"+mr.Addr(), consumer, 50*time.Millisecond)
+		if err != nil {
+			t.Fatalf("NewJobQueue() error = %v", err)
+		}
+		return q
+	}
+	es := NewExtractionService(extractor.NewExtractor("test", time.Second), cache).
+		WithHeartbeat(5 * time.Millisecond).
+		WithQueue(newQueue("worker"))
+
+	options := data.Options{BaseUrl: server.URL + "/"}
+	if _, err := es.QueueJob(options); err != nil {
+		t.Fatalf("QueueJob() error = %v", err)
+	}
+	// a worker receives the job and vanishes before taking it
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := newQueue("vanished").Receive(ctx); err != nil {
+		t.Fatalf("Receive() error = %v", err)
+	}
+	time.Sleep(60 * time.Millisecond)
+
+	if err := es.RecoverAbandoned(); err != nil {
+		t.Fatalf("RecoverAbandoned() error = %v", err)
+	}
+	if status, _ := cache.GetStatus(options.Hash()); status != data.JobQueued {
+		t.Errorf("status of a job waiting longer than the processing TTL = %v, want %v", status, data.JobQueued)
+	}
+	if abandoned, _ := cache.Abandoned(); len(abandoned) != 0 {
+		t.Errorf("Abandoned() = %v, want queued jobs left alone", abandoned)
+	}
+
+	workCtx, stop := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		es.Work(workCtx, 1)
+		close(stopped)
+	}()
+	defer func() {
+		stop()
+		<-stopped
+	}()
+	waitForStatus(t, cache, options.Hash(), data.JobDone)
+	result, err := es.GetJob(options.Hash())
+	if err != nil || len(result.Boards) != 1 {
+		t.Errorf("GetJob() of a redelivered job = %d boards, %v, want 1", len(result.Boards), err)
+	}
+}
+
+func TestExtractionService_CancelJob(t *testing.T) {
+	tests := []struct {
+		name string
+		// otherProcess cancels through a second service sharing the cache, which has to be polled
+		otherProcess bool
+	}{
+		{name: "same process"},
+		{name: "other process", otherProcess: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reached := make(chan struct{}, 1)
+			var mu sync.Mutex
+			var lastRequested string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				lastRequested = r.URL.Path
+				mu.Unlock()
+				switch r.URL.Path {
+				case "/settings.json":
+					_, _ = w.Write([]byte(`{"BoardsNumbers": [1, 2, 3], "FullName": "Test Pairs"}`))
+				case "/p1.json":
+					_, _ = w.Write([]byte(testProtocol))
+				default:
+					// board 2 hangs until its request is cancelled
+					reached <- struct{}{}
+					<-r.Context().Done()
+				}
+			}))
+			defer server.Close()
+			cache := newTestCache(t)
+			es := NewExtractionService(extractor.NewExtractor("test", time.Minute), cache)
+			es.cancelPoll = 20 * time.Millisecond
+			canceller := es
+			if tt.otherProcess {
+				canceller = NewExtractionService(extractor.NewExtractor("test", time.Minute), cache)
+			}
+
+			options := data.Options{BaseUrl: server.URL + "/"}
+			id, _ := es.QueueJob(options)
+			<-reached
+			if err := canceller.CancelJob(id); err != nil {
+				t.Fatalf("CancelJob() error = %v", err)
+			}
+			waitForStatus(t, cache, id, data.JobCancelled)
+			result, err := es.GetJob(id)
+			if err != nil || len(result.Boards) != 1 {
+				t.Fatalf("GetJob() = %d boards, %v, want the board extracted before cancellation", len(result.Boards), err)
+			}
+			if len(result.Errors) != 1 || result.Errors[0] != ErrJobCancelled.Error() {
+				t.Errorf("GetJob() errors = %v, want %v", result.Errors, ErrJobCancelled)
+			}
+			if lastRequested != "/p2.json" {
+				t.Errorf("last request = %s, want none after the cancelled board 2", lastRequested)
+			}
+			if err = canceller.CancelJob(id); !errors.Is(err, ErrJobNotRunning) {
+				t.Errorf("CancelJob() of a cancelled job error = %v, want %v", err, ErrJobNotRunning)
+			}
+		})
+	}
+	es := NewExtractionService(extractor.NewExtractor("test", time.Second), newTestCache(t))
+	if err := es.CancelJob("unknown"); !errors.Is(err, ErrJobNotFound) {
+		t.Errorf("CancelJob() of an unknown job error = %v, want %v", err, ErrJobNotFound)
+	}
+}
@@ -0,0 +1,28 @@
+package app
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+//go:embed web
+var webFiles embed.FS
+
+// registerWebUI serves the front end, index.html at the root and everything else of the web directory under /static.
+func registerWebUI(router *gin.Engine) {
+	static, err := fs.Sub(webFiles, "web")
+	if err != nil {
+		panic(err)
+	}
+	index, err := fs.ReadFile(static, "index.html")
+	if err != nil {
+		panic(err)
+	}
+	router.StaticFS("static", http.FS(static))
+	router.GET("", func(ctx *gin.Context) {
+		ctx.Data(http.StatusOK, "text/html; charset=utf-8", index)
+	})
+}
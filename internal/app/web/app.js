@@ -0,0 +1,225 @@
+"use strict";
+
+const formats = [
+    {format: "pbn", label: "PBN"},
+    {format: "json", label: "JSON"},
+    {format: "csv", label: "CSV"},
+    {format: "text", label: "Text"},
+    {format: "markdown", label: "Markdown"},
+];
+
+const form = document.getElementById("job-form");
+const jobSection = document.getElementById("job");
+const jobTitle = document.getElementById("job-title");
+const jobStatus = document.getElementById("job-status");
+const jobProgress = document.getElementById("job-progress");
+const jobCancel = document.getElementById("job-cancel");
+const downloads = document.getElementById("downloads");
+const errors = document.getElementById("errors");
+const boards = document.getElementById("boards");
+const recent = document.getElementById("recent");
+const recentJobs = document.getElementById("recent-jobs");
+
+let events = null;
+let currentJob = null;
+
+form.addEventListener("submit", async (e) => {
+    e.preventDefault();
+    const fields = form.elements;
+    const options = {
+        baseUrl: fields.baseUrl.value.trim(),
+        eventName: fields.eventName.value.trim(),
+        boardsRange: fields.boardsRange.value.trim(),
+        splitOnDiscontinuation: fields.splitOnDiscontinuation.checked,
+        fillMissing: fields.fillMissing.checked,
+        forceRefresh: fields.forceRefresh.checked,
+    };
+    resetJob();
+    try {
+        const response = await fetch("jobs", {
+            method: "POST",
+            headers: {"Content-Type": "application/json"},
+            body: JSON.stringify(options),
+        });
+        const body = await response.json();
+        if (!response.ok) {
+            showError(body.error || response.statusText);
+            jobStatus.textContent = "Extraction could not be started.";
+            return;
+        }
+        location.hash = body.id;
+        follow(body.id);
+    } catch (err) {
+        showError(err.message);
+    }
+});
+
+jobCancel.addEventListener("click", async () => {
+    jobCancel.disabled = true;
+    const response = await fetch(`jobs/${encodeURIComponent(currentJob)}`, {method: "DELETE"});
+    if (!response.ok) {
+        const body = await response.json();
+        showError(body.error || response.statusText);
+        jobCancel.disabled = false;
+        return;
+    }
+    jobStatus.textContent = "Cancelling…";
+});
+
+window.addEventListener("load", () => {
+    if (location.hash.length > 1) {
+        resetJob();
+        follow(location.hash.substring(1));
+    }
+    loadRecent();
+});
+
+// loadRecent lists jobs kept by the server, the section stays hidden when its results cache keeps no history
+async function loadRecent() {
+    const response = await fetch("jobs?limit=10");
+    if (!response.ok) {
+        return;
+    }
+    const body = await response.json();
+    recentJobs.replaceChildren(...body.jobs.map((job) => {
+        const item = document.createElement("li");
+        const link = document.createElement("a");
+        link.href = `#${job.id}`;
+        link.textContent = job.eventName || job.baseUrl;
+        link.addEventListener("click", () => {
+            resetJob();
+            follow(job.id);
+        });
+        item.append(link, ` — ${new Date(job.created).toLocaleString()}, ${job.boards} boards${job.cancelled ? ", cancelled" : ""}`);
+        return item;
+    }));
+    recent.hidden = body.jobs.length === 0;
+}
+
+function resetJob() {
+    if (events !== null) {
+        events.close();
+        events = null;
+    }
+    jobSection.hidden = false;
+    jobTitle.textContent = "Extraction";
+    jobStatus.textContent = "Starting…";
+    jobProgress.removeAttribute("value");
+    jobCancel.hidden = true;
+    jobCancel.disabled = false;
+    downloads.replaceChildren();
+    errors.replaceChildren();
+    boards.replaceChildren();
+}
+
+function follow(id) {
+    currentJob = id;
+    jobCancel.hidden = false;
+    events = new EventSource(`jobs/${encodeURIComponent(id)}/events`);
+    events.addEventListener("progress", (e) => showProgress(JSON.parse(e.data).progress));
+    events.addEventListener("fetched", (e) => addBoard(JSON.parse(e.data), "fetched"));
+    events.addEventListener("failed", (e) => addBoard(JSON.parse(e.data), "failed"));
+    events.addEventListener("serialized", (e) => addBoard(JSON.parse(e.data), "serialized"));
+    events.addEventListener("done", (e) => {
+        events.close();
+        showDone(JSON.parse(e.data));
+    });
+    events.addEventListener("error", (e) => {
+        events.close();
+        if (e.data) {
+            jobCancel.hidden = true;
+            showError(JSON.parse(e.data).error);
+            jobStatus.textContent = "Extraction failed.";
+            return;
+        }
+        // the stream was cut, the job may still be running or not exist at all
+        fetch(`jobs/${encodeURIComponent(id)}`).then(async (response) => {
+            const body = await response.json();
+            if (response.status === 200) {
+                showDone(body);
+            } else if (response.status === 202) {
+                setTimeout(() => follow(id), 2000);
+            } else {
+                jobCancel.hidden = true;
+                showError(body.error || response.statusText);
+                jobStatus.textContent = "";
+            }
+        });
+    });
+}
+
+function showProgress(progress) {
+    if (!progress) {
+        jobStatus.textContent = "Waiting for the tournament…";
+        return;
+    }
+    const done = progress.fetched + progress.failed;
+    if (progress.total > 0) {
+        jobProgress.max = progress.total;
+        jobProgress.value = done;
+    }
+    let status = progress.total > 0 ? `${done} of ${progress.total} boards downloaded` : "Reading tournament settings";
+    if (progress.failed > 0) {
+        status += `, ${progress.failed} failed`;
+    }
+    if (progress.currentBoard) {
+        status += `, now board ${progress.currentBoard}`;
+    }
+    if (progress.eta) {
+        const seconds = Math.max(0, Math.round((new Date(progress.eta) - new Date()) / 1000));
+        status += `, about ${seconds}s left`;
+    }
+    jobStatus.textContent = status + ".";
+}
+
+function addBoard(event, kind) {
+    const item = document.createElement("li");
+    item.className = kind;
+    switch (kind) {
+        case "fetched":
+            item.textContent = `Board ${event.board} downloaded`;
+            break;
+        case "serialized":
+            item.textContent = `Board ${event.board} (as played) saved`;
+            break;
+        default:
+            item.textContent = `Board ${event.board} failed: ${event.error}`;
+    }
+    boards.appendChild(item);
+}
+
+function showDone(job) {
+    location.hash = job.id;
+    loadRecent();
+    jobTitle.textContent = job.eventName || "Extraction";
+    jobProgress.max = 1;
+    jobProgress.value = 1;
+    jobCancel.hidden = true;
+    if (job.status === "cancelled") {
+        jobStatus.textContent = `Cancelled, ${job.boards} boards extracted.`;
+    } else {
+        jobStatus.textContent = job.success ? `Done, ${job.boards} boards extracted.` : "Extraction failed.";
+    }
+    (job.errors || []).forEach(showError);
+
+    const table = document.createElement("table");
+    (job.downloads || []).forEach((link, i) => {
+        const row = table.insertRow();
+        row.insertCell().textContent = job.downloads.length > 1 ? `Board set ${i + 1}` : "Boards";
+        const cell = row.insertCell();
+        formats.forEach(({format, label}) => {
+            const a = document.createElement("a");
+            a.href = `${link.replace(/^\//, "")}?format=${format}`;
+            a.textContent = label;
+            a.setAttribute("download", "");
+            cell.appendChild(a);
+        });
+    });
+    downloads.replaceChildren(table);
+}
+
+function showError(message) {
+    const item = document.createElement("li");
+    item.textContent = message;
+    errors.appendChild(item);
+}
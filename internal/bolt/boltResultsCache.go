@@ -0,0 +1,570 @@
+package bolt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/fe-dox/tc-pbn-extractor/internal/data"
+	bolt "go.etcd.io/bbolt"
+)
+
+// DefaultRetention is how long finished jobs are kept when no retention is configured.
+const DefaultRetention = 30 * 24 * time.Hour
+
+// SweepInterval is how often expired jobs are deleted in the background, reads never see them anyway.
+const SweepInterval = time.Hour
+
+var (
+	jobsBucket       = []byte("jobs")
+	processingBucket = []byte("processing")
+	queuedBucket     = []byte("queued")
+	pendingBucket    = []byte("pending")
+	progressBucket   = []byte("progress")
+	byCreatedBucket  = []byte("by_created")
+	byUrlBucket      = []byte("by_url")
+)
+
+type record struct {
+	Created   time.Time
+	Expires   time.Time
+	Cancelled bool `json:",omitempty"`
+	Result    data.Result
+}
+
+// expiring is a claim, queued marker or progress of a job. Cancelled is set on claims and queued markers of jobs
+// asked to stop.
+type expiring struct {
+	Expires   time.Time
+	Owner     string        `json:",omitempty"`
+	Cancelled bool          `json:",omitempty"`
+	Progress  data.Progress `json:",omitempty"`
+}
+
+// ResultsCache keeps jobs in a bbolt database, so results survive restarts and stay for the retention period.
+// Finished jobs are indexed by job hash, which is the key of the jobs bucket, by creation time and by base URL
+// followed by creation time. Index values hold a data.HistoryEntry, so listing never decodes whole results.
+type ResultsCache struct {
+	db            *bolt.DB
+	retention     time.Duration
+	processingTTL time.Duration
+	now           func() time.Time
+	stop          chan struct{}
+	stopOnce      sync.Once
+}
+
+// NewResultsCache opens or creates the database at path. Zero durations fall back to DefaultRetention and
+// data.DefaultProcessingTTL.
+func NewResultsCache(path string, retention time.Duration, processingTTL time.Duration) (*ResultsCache, error) {
+	if retention == 0 {
+		retention = DefaultRetention
+	}
+	if processingTTL == 0 {
+		processingTTL = data.DefaultProcessingTTL
+	}
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{jobsBucket, processingBucket, queuedBucket, pendingBucket, progressBucket, byCreatedBucket, byUrlBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	r := &ResultsCache{
+		db:            db,
+		retention:     retention,
+		processingTTL: processingTTL,
+		now:           time.Now,
+		stop:          make(chan struct{}),
+	}
+	go r.sweepPeriodically(SweepInterval)
+	return r, nil
+}
+
+// Close stops background sweeping and closes the database.
+func (r *ResultsCache) Close() error {
+	r.stopOnce.Do(func() {
+		close(r.stop)
+	})
+	return r.db.Close()
+}
+
+func (r *ResultsCache) Get(key string) (data.JobStatus, data.Result, error) {
+	var status data.JobStatus
+	var rec record
+	err := r.db.View(func(tx *bolt.Tx) error {
+		var err error
+		status, err = r.status(tx, key)
+		if err != nil || (status != data.JobDone && status != data.JobCancelled) {
+			return err
+		}
+		return json.Unmarshal(tx.Bucket(jobsBucket).Get([]byte(key)), &rec)
+	})
+	if err != nil {
+		return data.JobNotFound, data.Result{}, err
+	}
+	return status, rec.Result, nil
+}
+
+func (r *ResultsCache) GetStatus(key string) (data.JobStatus, error) {
+	var status data.JobStatus
+	err := r.db.View(func(tx *bolt.Tx) error {
+		var err error
+		status, err = r.status(tx, key)
+		return err
+	})
+	return status, err
+}
+
+// status checks the claim first, a job claimed again while its previous result is kept is processing.
+func (r *ResultsCache) status(tx *bolt.Tx, key string) (data.JobStatus, error) {
+	var marker expiring
+	found, err := r.getExpiring(tx.Bucket(processingBucket), key, &marker)
+	if err != nil {
+		return data.JobNotFound, err
+	}
+	if found {
+		return data.JobProcessing, nil
+	}
+	found, err = r.getExpiring(tx.Bucket(queuedBucket), key, &marker)
+	if err != nil {
+		return data.JobNotFound, err
+	}
+	if found {
+		return data.JobQueued, nil
+	}
+	var rec record
+	found, err = r.getExpiring(tx.Bucket(jobsBucket), key, &rec)
+	if err != nil || !found {
+		return data.JobNotFound, err
+	}
+	if rec.Cancelled {
+		return data.JobCancelled, nil
+	}
+	return data.JobDone, nil
+}
+
+func (r *ResultsCache) SaveResult(key string, value data.Result) error {
+	return r.saveResult(key, value, false)
+}
+
+func (r *ResultsCache) SaveCancelled(key string, value data.Result) error {
+	return r.saveResult(key, value, true)
+}
+
+func (r *ResultsCache) saveResult(key string, value data.Result, cancelled bool) error {
+	now := r.now()
+	rec := record{Created: now, Expires: now.Add(r.retention), Cancelled: cancelled, Result: value}
+	parsedRecord, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	entry, err := json.Marshal(historyEntry(key, rec))
+	if err != nil {
+		return err
+	}
+	return r.db.Update(func(tx *bolt.Tx) error {
+		err := deleteJob(tx, key)
+		if err != nil {
+			return err
+		}
+		err = tx.Bucket(pendingBucket).Delete([]byte(key))
+		if err != nil {
+			return err
+		}
+		err = tx.Bucket(jobsBucket).Put([]byte(key), parsedRecord)
+		if err != nil {
+			return err
+		}
+		err = tx.Bucket(byCreatedBucket).Put(createdKey(rec.Created, key), entry)
+		if err != nil {
+			return err
+		}
+		return tx.Bucket(byUrlBucket).Put(urlKey(value.BaseUrl, rec.Created, key), entry)
+	})
+}
+
+// Claim checks and sets the claim in a single read-write transaction, bbolt allows only one at a time.
+func (r *ResultsCache) Claim(key string, owner string, options data.Options) (bool, error) {
+	claimed := false
+	err := r.db.Update(func(tx *bolt.Tx) error {
+		var claim expiring
+		found, err := r.getExpiring(tx.Bucket(processingBucket), key, &claim)
+		if err != nil || found {
+			return err
+		}
+		claimed = true
+		return r.claim(tx, key, expiring{Owner: owner}, options)
+	})
+	return claimed && err == nil, err
+}
+
+func (r *ResultsCache) Queue(key string, options data.Options) (bool, error) {
+	queued := false
+	err := r.db.Update(func(tx *bolt.Tx) error {
+		var marker expiring
+		for _, name := range [][]byte{processingBucket, queuedBucket} {
+			found, err := r.getExpiring(tx.Bucket(name), key, &marker)
+			if err != nil || found {
+				return err
+			}
+		}
+		parsedData, err := json.Marshal(expiring{Expires: r.now().Add(data.QueuedTTL)})
+		if err != nil {
+			return err
+		}
+		queued = true
+		return tx.Bucket(queuedBucket).Put([]byte(key), parsedData)
+	})
+	return queued && err == nil, err
+}
+
+func (r *ResultsCache) Take(key string, owner string, options data.Options) (bool, error) {
+	taken := false
+	err := r.db.Update(func(tx *bolt.Tx) error {
+		var claim, marker expiring
+		found, err := r.getExpiring(tx.Bucket(processingBucket), key, &claim)
+		if err != nil || found {
+			return err
+		}
+		found, err = r.getExpiring(tx.Bucket(queuedBucket), key, &marker)
+		if err != nil || !found {
+			return err
+		}
+		err = tx.Bucket(queuedBucket).Delete([]byte(key))
+		if err != nil {
+			return err
+		}
+		taken = true
+		return r.claim(tx, key, expiring{Owner: owner, Cancelled: marker.Cancelled}, options)
+	})
+	return taken && err == nil, err
+}
+
+// claim puts the claim with a fresh TTL and counts an attempt of the pending job.
+func (r *ResultsCache) claim(tx *bolt.Tx, key string, claim expiring, options data.Options) error {
+	claim.Expires = r.now().Add(r.processingTTL)
+	parsedData, err := json.Marshal(claim)
+	if err != nil {
+		return err
+	}
+	err = tx.Bucket(processingBucket).Put([]byte(key), parsedData)
+	if err != nil {
+		return err
+	}
+	pending := data.PendingJob{Options: options}
+	if raw := tx.Bucket(pendingBucket).Get([]byte(key)); raw != nil {
+		err = json.Unmarshal(raw, &pending)
+		if err != nil {
+			return err
+		}
+	}
+	pending.Options = options
+	pending.Attempts++
+	parsedPending, err := json.Marshal(pending)
+	if err != nil {
+		return err
+	}
+	return tx.Bucket(pendingBucket).Put([]byte(key), parsedPending)
+}
+
+func (r *ResultsCache) Abandoned() (map[string]data.PendingJob, error) {
+	abandoned := make(map[string]data.PendingJob)
+	err := r.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(pendingBucket).ForEach(func(k, v []byte) error {
+			var marker expiring
+			for _, name := range [][]byte{processingBucket, queuedBucket} {
+				found, err := r.getExpiring(tx.Bucket(name), string(k), &marker)
+				if err != nil || found {
+					return err
+				}
+			}
+			var pending data.PendingJob
+			err := json.Unmarshal(v, &pending)
+			if err != nil {
+				return err
+			}
+			abandoned[string(k)] = pending
+			return nil
+		})
+	})
+	return abandoned, err
+}
+
+func (r *ResultsCache) Release(key string, owner string) error {
+	return r.ifOwner(key, owner, func(b *bolt.Bucket, _ expiring) error {
+		return b.Delete([]byte(key))
+	})
+}
+
+func (r *ResultsCache) Refresh(key string, owner string) error {
+	return r.ifOwner(key, owner, func(b *bolt.Bucket, claim expiring) error {
+		claim.Expires = r.now().Add(r.processingTTL)
+		parsedData, err := json.Marshal(claim)
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(key), parsedData)
+	})
+}
+
+func (r *ResultsCache) Cancel(key string) error {
+	return r.db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{processingBucket, queuedBucket} {
+			var marker expiring
+			b := tx.Bucket(name)
+			found, err := r.getExpiring(b, key, &marker)
+			if err != nil {
+				return err
+			}
+			if !found {
+				continue
+			}
+			marker.Cancelled = true
+			parsedData, err := json.Marshal(marker)
+			if err != nil {
+				return err
+			}
+			return b.Put([]byte(key), parsedData)
+		}
+		return data.ErrNotClaimed
+	})
+}
+
+func (r *ResultsCache) CancelRequested(key string) (bool, error) {
+	cancelled := false
+	err := r.db.View(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{processingBucket, queuedBucket} {
+			var marker expiring
+			found, err := r.getExpiring(tx.Bucket(name), key, &marker)
+			if err != nil || found {
+				cancelled = marker.Cancelled
+				return err
+			}
+		}
+		return nil
+	})
+	return cancelled, err
+}
+
+func (r *ResultsCache) ifOwner(key string, owner string, fn func(b *bolt.Bucket, claim expiring) error) error {
+	return r.db.Update(func(tx *bolt.Tx) error {
+		var claim expiring
+		b := tx.Bucket(processingBucket)
+		found, err := r.getExpiring(b, key, &claim)
+		if err != nil {
+			return err
+		}
+		if !found || claim.Owner != owner {
+			return data.ErrClaimLost
+		}
+		return fn(b, claim)
+	})
+}
+
+func (r *ResultsCache) GetProgress(key string) (data.Progress, error) {
+	var progress expiring
+	err := r.db.View(func(tx *bolt.Tx) error {
+		_, err := r.getExpiring(tx.Bucket(progressBucket), key, &progress)
+		return err
+	})
+	return progress.Progress, err
+}
+
+// SaveProgress keeps progress as long as a result would be kept, so finished jobs still report their totals.
+func (r *ResultsCache) SaveProgress(key string, progress data.Progress) error {
+	return r.putExpiring(progressBucket, key, expiring{Expires: r.now().Add(r.retention), Progress: progress})
+}
+
+// Recent lists finished jobs newest first, walking the creation time index or, for a base URL, its part of the
+// base URL index.
+func (r *ResultsCache) Recent(baseUrl string, limit int) ([]data.HistoryEntry, error) {
+	entries := make([]data.HistoryEntry, 0)
+	err := r.db.View(func(tx *bolt.Tx) error {
+		var c *bolt.Cursor
+		var prefix []byte
+		var k, v []byte
+		if baseUrl == "" {
+			c = tx.Bucket(byCreatedBucket).Cursor()
+			k, v = c.Last()
+		} else {
+			c = tx.Bucket(byUrlBucket).Cursor()
+			prefix = append([]byte(baseUrl), 0)
+			k, v = c.Seek(append(append([]byte{}, prefix...), bytes.Repeat([]byte{0xff}, 9)...))
+			if k == nil {
+				k, v = c.Last()
+			} else {
+				k, v = c.Prev()
+			}
+		}
+		for ; k != nil && bytes.HasPrefix(k, prefix) && (limit <= 0 || len(entries) < limit); k, v = c.Prev() {
+			var entry data.HistoryEntry
+			err := json.Unmarshal(v, &entry)
+			if err != nil {
+				return err
+			}
+			if !r.now().Before(entry.Created.Add(r.retention)) {
+				continue
+			}
+			entries = append(entries, entry)
+		}
+		return nil
+	})
+	return entries, err
+}
+
+// Sweep deletes expired jobs along with their index entries, expired claims, queued markers and progress.
+func (r *ResultsCache) Sweep() error {
+	now := r.now()
+	return r.db.Update(func(tx *bolt.Tx) error {
+		var expiredJobs [][]byte
+		err := tx.Bucket(jobsBucket).ForEach(func(k, v []byte) error {
+			var rec record
+			if err := json.Unmarshal(v, &rec); err != nil {
+				return err
+			}
+			if !now.Before(rec.Expires) {
+				expiredJobs = append(expiredJobs, append([]byte{}, k...))
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, k := range expiredJobs {
+			if err = deleteJob(tx, string(k)); err != nil {
+				return err
+			}
+		}
+		for _, name := range [][]byte{processingBucket, queuedBucket, progressBucket} {
+			if err = sweepExpiring(tx.Bucket(name), now); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *ResultsCache) sweepPeriodically(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			_ = r.Sweep()
+		case <-r.stop:
+			return
+		}
+	}
+}
+
+func (r *ResultsCache) putExpiring(bucket []byte, key string, value expiring) error {
+	parsedData, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return r.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket).Put([]byte(key), parsedData)
+	})
+}
+
+// getExpiring decodes a value into v and reports whether it exists and has not expired yet.
+func (r *ResultsCache) getExpiring(b *bolt.Bucket, key string, v interface{}) (bool, error) {
+	raw := b.Get([]byte(key))
+	if raw == nil {
+		return false, nil
+	}
+	var expires struct {
+		Expires time.Time
+	}
+	err := json.Unmarshal(raw, &expires)
+	if err != nil {
+		return false, err
+	}
+	if !r.now().Before(expires.Expires) {
+		return false, nil
+	}
+	return true, json.Unmarshal(raw, v)
+}
+
+func sweepExpiring(b *bolt.Bucket, now time.Time) error {
+	var expired [][]byte
+	err := b.ForEach(func(k, v []byte) error {
+		var value expiring
+		if err := json.Unmarshal(v, &value); err != nil {
+			return err
+		}
+		if !now.Before(value.Expires) {
+			expired = append(expired, append([]byte{}, k...))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, k := range expired {
+		if err = b.Delete(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteJob removes a finished job and its index entries, it is a no-op for unknown jobs.
+func deleteJob(tx *bolt.Tx, key string) error {
+	jobs := tx.Bucket(jobsBucket)
+	raw := jobs.Get([]byte(key))
+	if raw == nil {
+		return nil
+	}
+	var rec record
+	err := json.Unmarshal(raw, &rec)
+	if err != nil {
+		return err
+	}
+	err = tx.Bucket(byCreatedBucket).Delete(createdKey(rec.Created, key))
+	if err != nil {
+		return err
+	}
+	err = tx.Bucket(byUrlBucket).Delete(urlKey(rec.Result.BaseUrl, rec.Created, key))
+	if err != nil {
+		return err
+	}
+	return jobs.Delete([]byte(key))
+}
+
+func historyEntry(key string, rec record) data.HistoryEntry {
+	return data.HistoryEntry{
+		Id:        key,
+		BaseUrl:   rec.Result.BaseUrl,
+		EventName: rec.Result.EventName,
+		Created:   rec.Created,
+		Success:   rec.Result.Success,
+		Cancelled: rec.Cancelled,
+		Boards:    len(rec.Result.Boards),
+		BoardSets: len(rec.Result.BoardSets),
+	}
+}
+
+// createdKey sorts by creation time, big endian nanoseconds keep byte order and time order the same.
+func createdKey(created time.Time, key string) []byte {
+	k := make([]byte, 8, 8+len(key))
+	binary.BigEndian.PutUint64(k, uint64(created.UnixNano()))
+	return append(k, key...)
+}
+
+// urlKey groups jobs by base URL, the zero byte ends the URL so one URL is never a prefix of another's keys.
+func urlKey(baseUrl string, created time.Time, key string) []byte {
+	k := append([]byte(baseUrl), 0)
+	return append(k, createdKey(created, key)...)
+}
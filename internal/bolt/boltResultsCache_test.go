@@ -0,0 +1,134 @@
+package bolt
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fe-dox/tc-pbn-extractor/internal/data"
+	"github.com/fe-dox/tc-pbn-extractor/internal/data/datatest"
+)
+
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+func openTestCache(t *testing.T, path string, c *clock) *ResultsCache {
+	r, err := NewResultsCache(path, 24*time.Hour, 0)
+	if err != nil {
+		t.Fatalf("NewResultsCache() error = %v", err)
+	}
+	r.now = c.Now
+	return r
+}
+
+func TestResultsCache_SurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.db")
+	c := &clock{now: time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)}
+	r := openTestCache(t, path, c)
+	_, _ = r.Claim("job", "owner", data.Options{})
+	if status, _ := r.GetStatus("job"); status != data.JobProcessing {
+		t.Fatalf("GetStatus() = %v, want processing", status)
+	}
+	_ = r.SaveResult("job", data.Result{Success: true, EventName: "Pairs", BaseUrl: "https://example.com/t1/"})
+	_ = r.Close()
+
+	r = openTestCache(t, path, c)
+	defer r.Close()
+	c.now = c.now.Add(23 * time.Hour)
+	status, result, err := r.Get("job")
+	if err != nil || status != data.JobDone || result.EventName != "Pairs" {
+		t.Fatalf("Get() after reopening = %v, %+v, %v, want done result", status, result, err)
+	}
+	c.now = c.now.Add(time.Hour)
+	if status, _ := r.GetStatus("job"); status != data.JobNotFound {
+		t.Fatalf("GetStatus() after retention = %v, want not found", status)
+	}
+	_ = r.Sweep()
+	if entries, _ := r.Recent("", 0); len(entries) != 0 {
+		t.Errorf("Recent() after sweep = %+v, want none", entries)
+	}
+}
+
+func TestResultsCache_Recent(t *testing.T) {
+	c := &clock{now: time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)}
+	r := openTestCache(t, filepath.Join(t.TempDir(), "results.db"), c)
+	defer r.Close()
+	jobs := []struct {
+		id      string
+		baseUrl string
+	}{
+		{"a", "https://example.com/t1/"},
+		{"b", "https://example.com/t10/"},
+		{"c", "https://example.com/t1/"},
+		{"d", "https://example.com/t2/"},
+	}
+	for _, job := range jobs {
+		c.now = c.now.Add(time.Minute)
+		_ = r.SaveResult(job.id, data.Result{BaseUrl: job.baseUrl})
+	}
+	// saving a job again moves it to the front instead of listing it twice
+	c.now = c.now.Add(time.Minute)
+	_ = r.SaveResult("a", data.Result{BaseUrl: "https://example.com/t1/"})
+
+	tests := []struct {
+		name    string
+		baseUrl string
+		limit   int
+		want    []string
+	}{
+		{name: "all", want: []string{"a", "d", "c", "b"}},
+		{name: "limited", limit: 2, want: []string{"a", "d"}},
+		{name: "by url", baseUrl: "https://example.com/t1/", want: []string{"a", "c"}},
+		{name: "last url", baseUrl: "https://example.com/t2/", want: []string{"d"}},
+		{name: "unknown url", baseUrl: "https://example.com/t3/", want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := r.Recent(tt.baseUrl, tt.limit)
+			if err != nil {
+				t.Fatalf("Recent() error = %v", err)
+			}
+			got := make([]string, 0, len(entries))
+			for _, entry := range entries {
+				got = append(got, entry.Id)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Recent() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("Recent() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestResultsCache_RecentCancelled(t *testing.T) {
+	c := &clock{now: time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)}
+	r := openTestCache(t, filepath.Join(t.TempDir(), "results.db"), c)
+	defer r.Close()
+	_ = r.SaveCancelled("job", data.Result{Success: true, BaseUrl: "https://example.com/t1/"})
+	entries, _ := r.Recent("", 0)
+	if len(entries) != 1 || !entries[0].Cancelled {
+		t.Errorf("Recent() = %+v, want the job listed as cancelled", entries)
+	}
+}
+
+func TestResultsCache_Conformance(t *testing.T) {
+	datatest.TestResultsCache(t, func(t *testing.T) (data.ResultsCache, func(time.Duration)) {
+		c := &clock{now: time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)}
+		r := openTestCache(t, filepath.Join(t.TempDir(), "results.db"), c)
+		t.Cleanup(func() {
+			_ = r.Close()
+		})
+		return r, func(d time.Duration) {
+			c.now = c.now.Add(d)
+		}
+	})
+}
@@ -0,0 +1,185 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	ErrUnknownProfile     = errors.New("unknown profile")
+	ErrUnsupportedFormat  = errors.New("unsupported config file format, use .yaml, .yml or .toml")
+	ErrInvalidProfileData = errors.New("profile must be a table of settings")
+)
+
+// FileNames are looked up, in order, in every XDG config directory under tcpbn/.
+var FileNames = []string{"config.yaml", "config.yml", "config.toml"}
+
+// Duration accepts strings like "1s" or "15m" in both YAML and TOML.
+type Duration time.Duration
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+type Extractor struct {
+	UserAgent string   `yaml:"agent" toml:"agent"`
+	Timeout   Duration `yaml:"timeout" toml:"timeout"`
+	Rate      Duration `yaml:"rate" toml:"rate"`
+	Retries   int      `yaml:"retries" toml:"retries"`
+}
+
+type Output struct {
+	Generator   string `yaml:"generator" toml:"generator"`
+	Format      string `yaml:"format" toml:"format"`
+	Dir         string `yaml:"dir" toml:"dir"`
+	Name        string `yaml:"name" toml:"name"`
+	FillMissing bool   `yaml:"fill-missing" toml:"fill-missing"`
+	Split       bool   `yaml:"split" toml:"split"`
+}
+
+type Server struct {
+	Addr          string   `yaml:"addr" toml:"addr"`
+	Cache         string   `yaml:"cache" toml:"cache"`
+	CacheMemory   int      `yaml:"cache-memory" toml:"cache-memory"`
+	Database      string   `yaml:"database" toml:"database"`
+	Retention     Duration `yaml:"retention" toml:"retention"`
+	Redis         string   `yaml:"redis" toml:"redis"`
+	Queue         string   `yaml:"queue" toml:"queue"`
+	Workers       int      `yaml:"workers" toml:"workers"`
+	ResultTTL     Duration `yaml:"result-ttl" toml:"result-ttl"`
+	ProcessingTTL Duration `yaml:"processing-ttl" toml:"processing-ttl"`
+}
+
+// Profile is a complete set of settings. The top level of a config file is a profile on its own, named profiles
+// under "profiles" override it key by key.
+type Profile struct {
+	Extractor Extractor `yaml:"extractor" toml:"extractor"`
+	Output    Output    `yaml:"output" toml:"output"`
+	Server    Server    `yaml:"server" toml:"server"`
+}
+
+// Keys are the settings a config file sets, as "section.key" like "extractor.retries". A key set to a zero value
+// is there too, so it can override a non-zero default.
+type Keys map[string]bool
+
+// Paths lists the config files that are searched when none is given explicitly, most specific first.
+func Paths() []string {
+	var dirs []string
+	if home := os.Getenv("XDG_CONFIG_HOME"); home != "" {
+		dirs = append(dirs, home)
+	} else if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, filepath.Join(home, ".config"))
+	}
+	configDirs := os.Getenv("XDG_CONFIG_DIRS")
+	if configDirs == "" {
+		configDirs = "/etc/xdg"
+	}
+	dirs = append(dirs, filepath.SplitList(configDirs)...)
+
+	paths := make([]string, 0, len(dirs)*len(FileNames))
+	for _, dir := range dirs {
+		for _, name := range FileNames {
+			paths = append(paths, filepath.Join(dir, "tcpbn", name))
+		}
+	}
+	return paths
+}
+
+// Find returns the first existing file out of Paths, or an empty string if there is none.
+func Find() string {
+	for _, path := range Paths() {
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+	}
+	return ""
+}
+
+// Load reads path and returns the selected profile along with the keys it sets. An empty profile name selects the
+// one named by the "profile" key of the file, or just the top level settings when that is empty too.
+func Load(path string, profile string) (Profile, Keys, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return Profile{}, nil, err
+	}
+	var raw map[string]interface{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, &raw)
+	case ".toml":
+		err = toml.Unmarshal(content, &raw)
+	default:
+		return Profile{}, nil, ErrUnsupportedFormat
+	}
+	if err != nil {
+		return Profile{}, nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return selectProfile(raw, profile)
+}
+
+func selectProfile(raw map[string]interface{}, profile string) (Profile, Keys, error) {
+	profiles, _ := raw["profiles"].(map[string]interface{})
+	if profile == "" {
+		profile, _ = raw["profile"].(string)
+	}
+	delete(raw, "profiles")
+	delete(raw, "profile")
+	if profile != "" {
+		overrides, ok := profiles[profile]
+		if !ok {
+			return Profile{}, nil, fmt.Errorf("%w %q", ErrUnknownProfile, profile)
+		}
+		overridesMap, ok := overrides.(map[string]interface{})
+		if !ok {
+			return Profile{}, nil, fmt.Errorf("%w: %q", ErrInvalidProfileData, profile)
+		}
+		merge(raw, overridesMap)
+	}
+
+	// both formats decode to plain maps, so the merged settings go through YAML to land in the typed profile
+	merged, err := yaml.Marshal(raw)
+	if err != nil {
+		return Profile{}, nil, err
+	}
+	var p Profile
+	err = yaml.Unmarshal(merged, &p)
+	if err != nil {
+		return Profile{}, nil, fmt.Errorf("invalid settings: %w", err)
+	}
+	keys := make(Keys)
+	for section, value := range raw {
+		settings, _ := value.(map[string]interface{})
+		for key := range settings {
+			keys[section+"."+key] = true
+		}
+	}
+	return p, keys, nil
+}
+
+func merge(dst map[string]interface{}, src map[string]interface{}) {
+	for key, value := range src {
+		srcMap, srcIsMap := value.(map[string]interface{})
+		dstMap, dstIsMap := dst[key].(map[string]interface{})
+		if srcIsMap && dstIsMap {
+			merge(dstMap, srcMap)
+			continue
+		}
+		dst[key] = value
+	}
+}
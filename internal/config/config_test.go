@@ -0,0 +1,126 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const yamlConfig = `
+profile: club
+extractor:
+  agent: tcpbn
+  timeout: 2s
+output:
+  generator: Club
+  fill-missing: true
+server:
+  addr: ":9000"
+profiles:
+  club:
+    output:
+      dir: /srv/club
+  federation:
+    extractor:
+      timeout: 10s
+    output:
+      format: json
+      fill-missing: false
+`
+
+const tomlConfig = `
+[extractor]
+agent = "tcpbn"
+timeout = "2s"
+
+[output]
+generator = "Club"
+fill-missing = true
+
+[profiles.federation.extractor]
+timeout = "10s"
+
+[profiles.federation.output]
+format = "json"
+fill-missing = false
+`
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	yamlPath := filepath.Join(dir, "config.yaml")
+	tomlPath := filepath.Join(dir, "config.toml")
+	if err := os.WriteFile(yamlPath, []byte(yamlConfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(tomlPath, []byte(tomlConfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+	base := Profile{
+		Extractor: Extractor{UserAgent: "tcpbn", Timeout: Duration(2 * time.Second)},
+		Output:    Output{Generator: "Club", FillMissing: true},
+	}
+	federation := Profile{
+		Extractor: Extractor{UserAgent: "tcpbn", Timeout: Duration(10 * time.Second)},
+		Output:    Output{Generator: "Club", Format: "json"},
+	}
+	club := base
+	club.Output.Dir = "/srv/club"
+	club.Server.Addr = ":9000"
+	yamlFederation := federation
+	yamlFederation.Server.Addr = ":9000"
+	tests := []struct {
+		name    string
+		path    string
+		profile string
+		want    Profile
+		wantErr error
+	}{
+		{name: "yaml default profile", path: yamlPath, want: club},
+		{name: "yaml named profile", path: yamlPath, profile: "federation", want: yamlFederation},
+		{name: "yaml unknown profile", path: yamlPath, profile: "county", wantErr: ErrUnknownProfile},
+		{name: "toml top level", path: tomlPath, want: base},
+		{name: "toml named profile", path: tomlPath, profile: "federation", want: federation},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, err := Load(tt.path, tt.profile)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Load() got = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad_Keys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	config := `
+extractor:
+  retries: 3
+  agent: tcpbn
+profiles:
+  offline:
+    extractor:
+      retries: 0
+      rate: 0s
+`
+	if err := os.WriteFile(path, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	got, keys, err := Load(path, "offline")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Extractor.Retries != 0 {
+		t.Errorf("Load() retries = %d, want the profile overriding them with 0", got.Extractor.Retries)
+	}
+	want := Keys{"extractor.retries": true, "extractor.agent": true, "extractor.rate": true}
+	if !reflect.DeepEqual(keys, want) {
+		t.Errorf("Load() keys = %v, want %v", keys, want)
+	}
+}
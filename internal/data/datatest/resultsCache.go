@@ -0,0 +1,264 @@
+// Package datatest checks implementations of the data interfaces against the behaviour their callers rely on.
+package datatest
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fe-dox/tc-pbn-extractor/internal/data"
+)
+
+// NewResultsCache returns an empty cache using data.DefaultProcessingTTL and a function moving its clock forward.
+type NewResultsCache func(t *testing.T) (data.ResultsCache, func(time.Duration))
+
+// TestResultsCache runs the ResultsCache conformance suite, every test gets a cache of its own.
+func TestResultsCache(t *testing.T, newCache NewResultsCache) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, r data.ResultsCache, advance func(time.Duration))
+	}{
+		{name: "claim", test: testClaim},
+		{name: "claim expires", test: testClaimExpires},
+		{name: "concurrent claims", test: testConcurrentClaims},
+		{name: "results", test: testResults},
+		{name: "queue and take", test: testQueueAndTake},
+		{name: "abandoned", test: testAbandoned},
+		{name: "cancel", test: testCancel},
+		{name: "progress", test: testProgress},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, advance := newCache(t)
+			tt.test(t, r, advance)
+		})
+	}
+}
+
+func testClaim(t *testing.T, r data.ResultsCache, advance func(time.Duration)) {
+	if claimed, err := r.Claim("job", "first", data.Options{}); !claimed || err != nil {
+		t.Fatalf("Claim(first) = %v, %v, want the claim", claimed, err)
+	}
+	if claimed, _ := r.Claim("job", "second", data.Options{}); claimed {
+		t.Fatalf("Claim(second) = true while first holds the claim")
+	}
+	if err := r.Release("job", "second"); !errors.Is(err, data.ErrClaimLost) {
+		t.Fatalf("Release(second) error = %v, want %v", err, data.ErrClaimLost)
+	}
+	advance(data.DefaultProcessingTTL - time.Second)
+	if err := r.Refresh("job", "first"); err != nil {
+		t.Fatalf("Refresh(first) error = %v", err)
+	}
+	advance(data.DefaultProcessingTTL - time.Second)
+	if status, _ := r.GetStatus("job"); status != data.JobProcessing {
+		t.Fatalf("GetStatus() after refresh = %v, want processing", status)
+	}
+	if err := r.Release("job", "first"); err != nil {
+		t.Fatalf("Release(first) error = %v", err)
+	}
+	if err := r.Refresh("job", "first"); !errors.Is(err, data.ErrClaimLost) {
+		t.Fatalf("Refresh() after release error = %v, want %v", err, data.ErrClaimLost)
+	}
+	if claimed, _ := r.Claim("job", "second", data.Options{}); !claimed {
+		t.Errorf("Claim(second) after release = false, want the claim")
+	}
+}
+
+func testClaimExpires(t *testing.T, r data.ResultsCache, advance func(time.Duration)) {
+	_, _ = r.Claim("job", "first", data.Options{})
+	advance(data.DefaultProcessingTTL)
+	if status, _ := r.GetStatus("job"); status != data.JobNotFound {
+		t.Fatalf("GetStatus() after processing TTL = %v, want not found", status)
+	}
+	if err := r.Refresh("job", "first"); !errors.Is(err, data.ErrClaimLost) {
+		t.Fatalf("Refresh() of an expired claim error = %v, want %v", err, data.ErrClaimLost)
+	}
+	if claimed, _ := r.Claim("job", "second", data.Options{}); !claimed {
+		t.Errorf("Claim(second) after the claim expired = false, want the claim")
+	}
+}
+
+func testConcurrentClaims(t *testing.T, r data.ResultsCache, advance func(time.Duration)) {
+	const claimers = 10
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	claims := 0
+	for i := 0; i < claimers; i++ {
+		wg.Add(1)
+		go func(owner string) {
+			defer wg.Done()
+			claimed, err := r.Claim("job", owner, data.Options{})
+			if err != nil {
+				t.Errorf("Claim(%s) error = %v", owner, err)
+			}
+			if claimed {
+				mu.Lock()
+				claims++
+				mu.Unlock()
+			}
+		}(fmt.Sprint("owner", i))
+	}
+	wg.Wait()
+	if claims != 1 {
+		t.Fatalf("%d of %d concurrent claims succeeded, want 1", claims, claimers)
+	}
+	advance(data.DefaultProcessingTTL)
+	if abandoned, err := r.Abandoned(); err != nil || abandoned["job"].Attempts != 1 {
+		t.Errorf("Abandoned() = %v, %v, want job after 1 attempt", abandoned, err)
+	}
+}
+
+func testResults(t *testing.T, r data.ResultsCache, _ func(time.Duration)) {
+	if status, _, err := r.Get("job"); err != nil || status != data.JobNotFound {
+		t.Fatalf("Get() of an unknown job = %v, %v, want not found", status, err)
+	}
+	_, _ = r.Claim("job", "first", data.Options{})
+	_ = r.SaveResult("job", data.Result{Success: true, EventName: "Pairs"})
+	if status, _ := r.GetStatus("job"); status != data.JobProcessing {
+		t.Fatalf("GetStatus() before release = %v, want processing", status)
+	}
+	_ = r.Release("job", "first")
+	status, result, err := r.Get("job")
+	if err != nil || status != data.JobDone || result.EventName != "Pairs" {
+		t.Fatalf("Get() after release = %v, %+v, %v, want done result", status, result, err)
+	}
+
+	_ = r.SaveCancelled("job", data.Result{Success: true, EventName: "Cancelled Pairs"})
+	status, result, err = r.Get("job")
+	if err != nil || status != data.JobCancelled || result.EventName != "Cancelled Pairs" {
+		t.Fatalf("Get() = %v, %+v, %v, want cancelled result", status, result, err)
+	}
+	_ = r.SaveResult("job", data.Result{Success: true})
+	if status, _ := r.GetStatus("job"); status != data.JobDone {
+		t.Errorf("GetStatus() after a new result = %v, want done", status)
+	}
+}
+
+func testQueueAndTake(t *testing.T, r data.ResultsCache, advance func(time.Duration)) {
+	options := data.Options{BaseUrl: "https://example.com/t1/"}
+	if queued, err := r.Queue("job", options); !queued || err != nil {
+		t.Fatalf("Queue() = %v, %v, want the job queued", queued, err)
+	}
+	if queued, _ := r.Queue("job", options); queued {
+		t.Fatalf("Queue() of a queued job = true, want false")
+	}
+	// a queued job waits for a worker as long as it takes, it is neither processing nor abandoned meanwhile
+	advance(2 * data.DefaultProcessingTTL)
+	if status, _ := r.GetStatus("job"); status != data.JobQueued {
+		t.Fatalf("GetStatus() after processing TTL = %v, want queued", status)
+	}
+	if abandoned, _ := r.Abandoned(); len(abandoned) != 0 {
+		t.Fatalf("Abandoned() = %v while the job is queued", abandoned)
+	}
+
+	if taken, err := r.Take("job", "first", options); !taken || err != nil {
+		t.Fatalf("Take(first) = %v, %v, want the job", taken, err)
+	}
+	if taken, _ := r.Take("job", "second", options); taken {
+		t.Fatalf("Take(second) = true after first took the job")
+	}
+	if queued, _ := r.Queue("job", options); queued {
+		t.Fatalf("Queue() of a claimed job = true, want false")
+	}
+	if status, _ := r.GetStatus("job"); status != data.JobProcessing {
+		t.Fatalf("GetStatus() after Take = %v, want processing", status)
+	}
+	if err := r.Refresh("job", "first"); err != nil {
+		t.Fatalf("Refresh(first) after Take error = %v", err)
+	}
+	advance(data.DefaultProcessingTTL)
+	abandoned, _ := r.Abandoned()
+	if abandoned["job"].Attempts != 1 || abandoned["job"].Options != options {
+		t.Errorf("Abandoned() = %v, want job taken once", abandoned)
+	}
+	if taken, _ := r.Take("job", "second", options); taken {
+		t.Errorf("Take() of a job that is not queued = true, want false")
+	}
+}
+
+func testAbandoned(t *testing.T, r data.ResultsCache, advance func(time.Duration)) {
+	options := data.Options{BaseUrl: "https://example.com/t1/"}
+	_, _ = r.Claim("job", "first", options)
+	advance(data.DefaultProcessingTTL - time.Second)
+	// a claim while the job is alive must neither succeed nor extend the claim
+	if claimed, _ := r.Claim("job", "second", options); claimed {
+		t.Fatalf("Claim(second) = true while first holds the claim")
+	}
+	if abandoned, _ := r.Abandoned(); len(abandoned) != 0 {
+		t.Fatalf("Abandoned() = %v while the job is claimed", abandoned)
+	}
+	advance(time.Second)
+	abandoned, err := r.Abandoned()
+	if err != nil || abandoned["job"].Attempts != 1 || abandoned["job"].Options != options {
+		t.Fatalf("Abandoned() = %v, %v, want job after 1 attempt", abandoned, err)
+	}
+	_, _ = r.Claim("job", "second", options)
+	advance(data.DefaultProcessingTTL)
+	if abandoned, _ = r.Abandoned(); abandoned["job"].Attempts != 2 {
+		t.Fatalf("Abandoned() = %v, want job after 2 attempts", abandoned)
+	}
+	_, _ = r.Claim("job", "third", options)
+	_ = r.SaveResult("job", data.Result{})
+	_ = r.Release("job", "third")
+	if abandoned, _ := r.Abandoned(); len(abandoned) != 0 {
+		t.Errorf("Abandoned() = %v after the result was saved", abandoned)
+	}
+}
+
+func testCancel(t *testing.T, r data.ResultsCache, advance func(time.Duration)) {
+	if err := r.Cancel("job"); !errors.Is(err, data.ErrNotClaimed) {
+		t.Fatalf("Cancel() of an unknown job error = %v, want %v", err, data.ErrNotClaimed)
+	}
+	_, _ = r.Claim("job", "first", data.Options{})
+	if requested, _ := r.CancelRequested("job"); requested {
+		t.Fatalf("CancelRequested() of a new claim = true, want false")
+	}
+	if err := r.Cancel("job"); err != nil {
+		t.Fatalf("Cancel() of a claimed job error = %v", err)
+	}
+	if requested, _ := r.CancelRequested("job"); !requested {
+		t.Fatalf("CancelRequested() after Cancel = false, want true")
+	}
+	_ = r.SaveCancelled("job", data.Result{Success: true, EventName: "Pairs"})
+	_ = r.Release("job", "first")
+	if err := r.Cancel("job"); !errors.Is(err, data.ErrNotClaimed) {
+		t.Fatalf("Cancel() of a finished job error = %v, want %v", err, data.ErrNotClaimed)
+	}
+
+	_, _ = r.Queue("job", data.Options{})
+	if requested, _ := r.CancelRequested("job"); requested {
+		t.Fatalf("CancelRequested() after queueing again = true, want the old request gone")
+	}
+	_ = r.Cancel("job")
+	advance(2 * data.DefaultProcessingTTL)
+	_, _ = r.Take("job", "second", data.Options{})
+	if requested, _ := r.CancelRequested("job"); !requested {
+		t.Fatalf("CancelRequested() after Take = false, want the request of the queued job kept")
+	}
+	_ = r.SaveCancelled("job", data.Result{Success: true})
+	_ = r.Release("job", "second")
+
+	_, _ = r.Claim("job", "third", data.Options{})
+	if requested, _ := r.CancelRequested("job"); requested {
+		t.Errorf("CancelRequested() after a new claim = true, want a fresh claim")
+	}
+}
+
+func testProgress(t *testing.T, r data.ResultsCache, _ func(time.Duration)) {
+	if progress, err := r.GetProgress("job"); err != nil || !progress.Started.IsZero() {
+		t.Fatalf("GetProgress() of an unknown job = %+v, %v, want none", progress, err)
+	}
+	started := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+	eta := started.Add(time.Minute)
+	want := data.Progress{Total: 10, Fetched: 4, Failed: 1, CurrentBoard: 6, Started: started, Eta: &eta}
+	if err := r.SaveProgress("job", want); err != nil {
+		t.Fatalf("SaveProgress() error = %v", err)
+	}
+	got, err := r.GetProgress("job")
+	if err != nil || got.Total != want.Total || got.Fetched != want.Fetched || got.Failed != want.Failed ||
+		got.CurrentBoard != want.CurrentBoard || !got.Started.Equal(started) || got.Eta == nil || !got.Eta.Equal(eta) {
+		t.Errorf("GetProgress() = %+v, %v, want %+v", got, err, want)
+	}
+}
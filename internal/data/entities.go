@@ -2,18 +2,20 @@ package data
 
 import (
 	"crypto/md5"
+	"encoding/json"
 	"fmt"
+	"time"
 )
 
 const GENERATOR = "pbnextractor.fedox.pl"
 
 type Options struct {
-	BaseUrl                string
-	EventName              string
-	BoardsRange            string
-	SplitOnDiscontinuation bool
-	ForceRefresh           bool
-	FillMissing            bool
+	BaseUrl                string `json:"baseUrl" binding:"required,url"`
+	EventName              string `json:"eventName"`
+	BoardsRange            string `json:"boardsRange"`
+	SplitOnDiscontinuation bool   `json:"splitOnDiscontinuation"`
+	ForceRefresh           bool   `json:"forceRefresh"`
+	FillMissing            bool   `json:"fillMissing"`
 }
 
 func (o *Options) Hash() string {
@@ -23,28 +25,82 @@ func (o *Options) Hash() string {
 type Result struct {
 	Success   bool
 	BoardSets []string
-	Errors    []error
+	// Boards are the extracted boards in the export JSON schema, fill-missing placeholders are only in BoardSets.
+	Boards    []json.RawMessage
+	Errors    []string
 	EventName string
+	BaseUrl   string
+	// BoardSetEnds holds, for every board set, the number of boards in Boards up to and including that set.
+	BoardSetEnds []int
 }
 
 func NewResult() *Result {
 	return &Result{
 		BoardSets: make([]string, 0),
-		Errors:    make([]error, 0),
+		Boards:    make([]json.RawMessage, 0),
+		Errors:    make([]string, 0),
 		Success:   false,
 		EventName: "",
 	}
 }
 
 func (r *Result) WithError(err error) *Result {
-	r.Errors = []error{err}
+	r.Errors = []string{err.Error()}
 	return r
 }
 
+// AddBoardSet closes a board set, all boards added since the previous set belong to it.
 func (r *Result) AddBoardSet(boardSet string) {
 	r.BoardSets = append(r.BoardSets, boardSet)
+	r.BoardSetEnds = append(r.BoardSetEnds, len(r.Boards))
+}
+
+// BoardsOfSet returns boards of the set with the given index, counted from 0. Results saved before sets were
+// tracked only know boards of a job that was not split.
+func (r *Result) BoardsOfSet(set int) []json.RawMessage {
+	if len(r.BoardSetEnds) != len(r.BoardSets) {
+		if len(r.BoardSets) == 1 && set == 0 {
+			return r.Boards
+		}
+		return nil
+	}
+	if set < 0 || set >= len(r.BoardSetEnds) {
+		return nil
+	}
+	start := 0
+	if set > 0 {
+		start = r.BoardSetEnds[set-1]
+	}
+	return r.Boards[start:r.BoardSetEnds[set]]
+}
+
+func (r *Result) AddBoard(board json.RawMessage) {
+	r.Boards = append(r.Boards, board)
 }
 
 func (r *Result) AddError(err error) {
-	r.Errors = append(r.Errors, err)
+	r.Errors = append(r.Errors, err.Error())
+}
+
+// Progress of a running job. Total is 0 until tournament settings are downloaded and the selection is known.
+type Progress struct {
+	Total        int        `json:"total"`
+	Fetched      int        `json:"fetched"`
+	Failed       int        `json:"failed"`
+	CurrentBoard int        `json:"currentBoard,omitempty"`
+	Started      time.Time  `json:"started"`
+	Eta          *time.Time `json:"eta,omitempty"`
+}
+
+// UpdateEta extrapolates the time taken by boards done so far to the remaining ones, there is no ETA before the
+// first board is done.
+func (p *Progress) UpdateEta(now time.Time) {
+	done := p.Fetched + p.Failed
+	if done == 0 || p.Total == 0 {
+		p.Eta = nil
+		return
+	}
+	perBoard := now.Sub(p.Started) / time.Duration(done)
+	eta := now.Add(perBoard * time.Duration(p.Total-done))
+	p.Eta = &eta
 }
@@ -0,0 +1,28 @@
+package data
+
+import "context"
+
+// QueuedJob is a job waiting for a worker. It is marked queued in the ResultsCache before it is enqueued, the
+// worker that receives it claims it with ResultsCache.Take.
+type QueuedJob struct {
+	Hash    string  `json:"hash"`
+	Options Options `json:"options"`
+	Attempt int     `json:"attempt"`
+}
+
+// Delivery is a job handed to a worker, it has to be acknowledged or retried. Id is assigned by the queue.
+type Delivery struct {
+	Id  string
+	Job QueuedJob
+}
+
+// JobQueue passes jobs from the API to workers. A delivery that is neither acknowledged nor retried, because its
+// worker vanished, may be delivered again.
+type JobQueue interface {
+	Enqueue(ctx context.Context, job QueuedJob) error
+	// Receive blocks until a job is delivered or ctx is done.
+	Receive(ctx context.Context) (Delivery, error)
+	Ack(ctx context.Context, delivery Delivery) error
+	// Retry enqueues a delivered job again, with its attempt counted, and acknowledges the delivery.
+	Retry(ctx context.Context, delivery Delivery) error
+}
@@ -1,16 +1,100 @@
 package data
 
+import (
+	"errors"
+	"time"
+)
+
 type JobStatus int
 
+const (
+	DefaultResultTTL     = 15 * time.Minute
+	DefaultProcessingTTL = 5 * time.Minute
+	// QueuedTTL bounds how long a job waits for a worker, it only matters when its queue message was lost.
+	QueuedTTL = 24 * time.Hour
+)
+
 const (
 	JobNotFound JobStatus = iota
 	JobProcessing
 	JobDone
+	JobCancelled
+	JobQueued
 )
 
+func (s JobStatus) String() string {
+	switch s {
+	case JobProcessing:
+		return "processing"
+	case JobDone:
+		return "done"
+	case JobCancelled:
+		return "cancelled"
+	case JobQueued:
+		return "queued"
+	default:
+		return "not_found"
+	}
+}
+
+var (
+	ErrClaimLost  = errors.New("job is no longer claimed by this owner")
+	ErrNotClaimed = errors.New("job is not claimed")
+)
+
+// PendingJob is a job that was claimed and has no result yet.
+type PendingJob struct {
+	Options  Options
+	Attempts int
+}
+
+// ResultsCache keeps jobs and their results. A job is processing while it is claimed, claims expire after the
+// processing TTL unless refreshed by their owner, a random token picked by whoever claims the job. Claimed jobs
+// stay pending until their result is saved, so jobs of owners that vanished can be found and recovered. Jobs
+// waiting for a worker of a JobQueue are queued instead, which lasts until a worker takes them and doesn't expire
+// with the processing TTL.
 type ResultsCache interface {
 	Get(key string) (JobStatus, Result, error)
 	GetStatus(key string) (JobStatus, error)
 	SaveResult(key string, value Result) error
-	SetStatusProcessing(key string) error
+	// SaveCancelled saves the partial result of a cancelled job, the job is JobCancelled instead of JobDone.
+	SaveCancelled(key string, value Result) error
+	// Claim marks a job processing unless it already is, atomically, and reports whether owner got the claim.
+	// An existing claim is left as it is, its TTL included. A successful claim makes the job pending and counts
+	// an attempt.
+	Claim(key string, owner string, options Options) (bool, error)
+	// Release ends a claim and Refresh starts its TTL over, both return ErrClaimLost if owner no longer holds it.
+	Release(key string, owner string) error
+	Refresh(key string, owner string) error
+	// Queue marks a job queued unless it is queued or claimed already, atomically, and reports whether it did.
+	Queue(key string, options Options) (bool, error)
+	// Take claims a queued job for owner and ends its queued state, counting an attempt like Claim. It reports
+	// false when the job is no longer queued or is claimed, whoever asked for it holds a duplicate.
+	Take(key string, owner string, options Options) (bool, error)
+	// Cancel asks the owner of a claimed or queued job to stop, the request lasts until the job is claimed or
+	// queued again, Take keeps it. It returns ErrNotClaimed otherwise. CancelRequested is polled by owners.
+	Cancel(key string) error
+	CancelRequested(key string) (bool, error)
+	GetProgress(key string) (Progress, error)
+	SaveProgress(key string, progress Progress) error
+	// Abandoned lists pending jobs that are neither claimed nor queued.
+	Abandoned() (map[string]PendingJob, error)
+}
+
+// HistoryEntry summarises a finished job kept by a ResultsHistory.
+type HistoryEntry struct {
+	Id        string    `json:"id"`
+	BaseUrl   string    `json:"baseUrl"`
+	EventName string    `json:"eventName"`
+	Created   time.Time `json:"created"`
+	Success   bool      `json:"success"`
+	Cancelled bool      `json:"cancelled,omitempty"`
+	Boards    int       `json:"boards"`
+	BoardSets int       `json:"boardSets"`
+}
+
+// ResultsHistory is implemented by results caches that keep finished jobs long enough to list them.
+type ResultsHistory interface {
+	// Recent lists finished jobs newest first, only those of baseUrl unless it is empty.
+	Recent(baseUrl string, limit int) ([]HistoryEntry, error)
 }
@@ -0,0 +1,19 @@
+package deal
+
+import "github.com/fe-dox/go-pbn"
+
+// vulnerabilities holds the standard 16 board vulnerability cycle. pbn.VulnerabilityFromBoardNumber repeats
+// every 4 boards, which is only right for boards 1 to 4.
+var vulnerabilities = [16]pbn.Vulnerability{
+	pbn.None, pbn.NorthSouth, pbn.EastWest, pbn.Both,
+	pbn.NorthSouth, pbn.EastWest, pbn.Both, pbn.None,
+	pbn.EastWest, pbn.Both, pbn.None, pbn.NorthSouth,
+	pbn.Both, pbn.None, pbn.NorthSouth, pbn.EastWest,
+}
+
+func VulnerabilityFromBoardNumber(n int) pbn.Vulnerability {
+	if n < 1 {
+		return pbn.None
+	}
+	return vulnerabilities[(n-1)%16]
+}
@@ -0,0 +1,99 @@
+package deal
+
+import (
+	"errors"
+
+	"github.com/fe-dox/go-pbn"
+)
+
+var ErrInvalidRotation = errors.New("rotation must be 0, 90, 180 or 270 degrees")
+
+// Transform returns a changed copy of a board, the board passed in is left untouched.
+type Transform func(board pbn.Board) pbn.Board
+
+// Chain applies transforms in the given order.
+func Chain(transforms ...Transform) Transform {
+	return func(board pbn.Board) pbn.Board {
+		for _, transform := range transforms {
+			board = transform(board)
+		}
+		return board
+	}
+}
+
+// Rotate turns the board clockwise, by 90 degrees the North hand moves to East. Dealer, vulnerability, double dummy
+// tricks and par move along with the hands, as if the physical board was turned on the table.
+func Rotate(degrees int) (Transform, error) {
+	if degrees < 0 || degrees >= 360 || degrees%90 != 0 {
+		return nil, ErrInvalidRotation
+	}
+	turns := degrees / 90
+	return func(board pbn.Board) pbn.Board {
+		return remap(board, func(d pbn.Direction) pbn.Direction {
+			return (d + pbn.Direction(turns)) % 4
+		}, turns%2 == 1)
+	}, nil
+}
+
+// SwapSides gives NS cards to EW and the other way round by moving every hand one seat clockwise. Simply
+// exchanging North with East and South with West would mirror the table, every hand would get another left-hand
+// opponent and double dummy tricks and par would no longer hold.
+func SwapSides() Transform {
+	return func(board pbn.Board) pbn.Board {
+		return remap(board, func(d pbn.Direction) pbn.Direction {
+			return (d + 1) % 4
+		}, true)
+	}
+}
+
+// Renumber numbers boards consecutively from first, in the order they are transformed. Dealer and vulnerability
+// are set to the ones of the new number, par is dropped when vulnerability changes as it depends on it.
+func Renumber(first int) Transform {
+	next := first
+	return func(board pbn.Board) pbn.Board {
+		board.Number = next
+		board.Dealer = pbn.DealerFromBoardNumber(next)
+		vulnerable := VulnerabilityFromBoardNumber(next)
+		if vulnerable != board.Vulnerable {
+			board.MinimaxScore = pbn.Contract{}
+			board.OptimumScore.Direction = 0
+			board.OptimumScore.Score = 0
+		}
+		board.Vulnerable = vulnerable
+		next++
+		return board
+	}
+}
+
+// remap moves everything tied to a seat from d to to(d). swapsSides tells whether NS end up sitting EW, which
+// flips vulnerability and the sign of the NS optimum score.
+func remap(board pbn.Board, to func(pbn.Direction) pbn.Direction, swapsSides bool) pbn.Board {
+	if board.Hands != nil {
+		hands := make(map[pbn.Direction]pbn.Hand, len(board.Hands))
+		for direction, hand := range board.Hands {
+			hands[to(direction)] = hand
+		}
+		board.Hands = hands
+	}
+	if board.Ability != nil {
+		ability := make(pbn.Ability, len(board.Ability))
+		for direction, tricks := range board.Ability {
+			ability[to(direction)] = tricks
+		}
+		board.Ability = ability
+	}
+	board.Dealer = to(board.Dealer)
+	if board.MinimaxScore.Level != 0 {
+		board.MinimaxScore.Direction = to(board.MinimaxScore.Direction)
+	}
+	if swapsSides {
+		switch board.Vulnerable {
+		case pbn.NorthSouth:
+			board.Vulnerable = pbn.EastWest
+		case pbn.EastWest:
+			board.Vulnerable = pbn.NorthSouth
+		}
+		board.OptimumScore.Score = -board.OptimumScore.Score
+	}
+	return board
+}
@@ -0,0 +1,127 @@
+package deal
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/fe-dox/go-pbn"
+)
+
+func parBoard() pbn.Board {
+	board := testBoard()
+	board.MinimaxScore = pbn.Contract{Level: 3, Suit: pbn.NoTrump, Direction: pbn.North, Score: 400}
+	board.OptimumScore.Direction = pbn.North
+	board.OptimumScore.Score = 400
+	board.Vulnerable = pbn.NorthSouth
+	return board
+}
+
+func TestRotate(t *testing.T) {
+	original := parBoard()
+	rotate, err := Rotate(90)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := rotate(original)
+	if !reflect.DeepEqual(got.Hands[pbn.East], original.Hands[pbn.North]) || !reflect.DeepEqual(got.Hands[pbn.North], original.Hands[pbn.West]) {
+		t.Errorf("Rotate(90) did not move hands clockwise")
+	}
+	if got.Ability[pbn.East][pbn.NoTrump] != 9 || got.Ability[pbn.North][pbn.NoTrump] != 4 {
+		t.Errorf("Rotate(90) ability = %v, want double dummy tricks moved with hands", got.Ability)
+	}
+	if got.Dealer != pbn.East || got.Vulnerable != pbn.EastWest {
+		t.Errorf("Rotate(90) dealer = %v, vulnerable = %v, want E and EW", got.Dealer, got.Vulnerable)
+	}
+	if got.MinimaxScore.Direction != pbn.East || got.OptimumScore.Score != -400 {
+		t.Errorf("Rotate(90) par = %+v, optimum = %d, want declared by E and NS -400", got.MinimaxScore, got.OptimumScore.Score)
+	}
+	if !reflect.DeepEqual(original, parBoard()) {
+		t.Errorf("Rotate(90) modified the original board")
+	}
+	for _, err := range Validate(got) {
+		if !IsWarning(err) {
+			t.Errorf("Rotate(90) produced an invalid deal: %v", err)
+		}
+	}
+
+	half, _ := Rotate(180)
+	got = half(original)
+	if got.Dealer != pbn.South || got.Vulnerable != pbn.NorthSouth || got.OptimumScore.Score != 400 {
+		t.Errorf("Rotate(180) dealer = %v, vulnerable = %v, optimum = %d, want S, NS and 400", got.Dealer, got.Vulnerable, got.OptimumScore.Score)
+	}
+
+	if _, err := Rotate(45); !errors.Is(err, ErrInvalidRotation) {
+		t.Errorf("Rotate(45) error = %v, want %v", err, ErrInvalidRotation)
+	}
+}
+
+func TestSwapSides(t *testing.T) {
+	original := parBoard()
+	got := SwapSides()(original)
+	for _, d := range []pbn.Direction{pbn.North, pbn.East, pbn.South, pbn.West} {
+		moved := (d + 1) % 4
+		if !reflect.DeepEqual(got.Hands[moved], original.Hands[d]) {
+			t.Errorf("SwapSides() moved the %v hand elsewhere than %v", d, moved)
+		}
+		// every hand keeps its left-hand opponent, otherwise double dummy tricks would change
+		if !reflect.DeepEqual(got.Hands[(moved+1)%4], original.Hands[(d+1)%4]) {
+			t.Errorf("SwapSides() changed the left-hand opponent of the %v hand", d)
+		}
+	}
+	// NS take 9 tricks in NT and EW 4 in the original deal, after the swap the same cards do so from EW
+	for _, d := range []pbn.Direction{pbn.East, pbn.West} {
+		if got.Ability[d][pbn.NoTrump] != 9 || got.Ability[(d+1)%4][pbn.NoTrump] != 4 {
+			t.Errorf("SwapSides() ability = %v, want 9 NT tricks for EW and 4 for NS", got.Ability)
+		}
+	}
+	if got.Vulnerable != pbn.EastWest || got.MinimaxScore.Direction != pbn.East || got.OptimumScore.Score != -400 {
+		t.Errorf("SwapSides() got vulnerable %v, par %+v, optimum %d, want EW, declared by E and NS -400", got.Vulnerable, got.MinimaxScore, got.OptimumScore.Score)
+	}
+}
+
+func TestRenumber(t *testing.T) {
+	renumber := Renumber(7)
+	var got []pbn.Board
+	for i := 0; i < 3; i++ {
+		got = append(got, renumber(testBoard()))
+	}
+	for i, board := range got {
+		number := 7 + i
+		if board.Number != number || board.Dealer != pbn.DealerFromBoardNumber(number) || board.Vulnerable != VulnerabilityFromBoardNumber(number) {
+			t.Errorf("Renumber(7) board %d = number %d, dealer %v, vulnerable %v", i, board.Number, board.Dealer, board.Vulnerable)
+		}
+		for _, err := range Validate(board) {
+			if errors.Is(err, ErrDealerMismatch) || errors.Is(err, ErrVulnerabilityMismatch) {
+				t.Errorf("Renumber(7) board %d: %v", number, err)
+			}
+		}
+	}
+}
+
+func TestRenumber_Par(t *testing.T) {
+	tests := []struct {
+		name    string
+		number  int
+		keepPar bool
+	}{
+		// board 2 is NS vulnerable like the par board, board 3 is EW vulnerable
+		{name: "same vulnerability", number: 2, keepPar: true},
+		{name: "other vulnerability", number: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Renumber(tt.number)(parBoard())
+			if kept := got.MinimaxScore.Level != 0 && got.OptimumScore.Score != 0; kept != tt.keepPar {
+				t.Errorf("Renumber(%d) par = %+v, optimum %d, want par kept = %v", tt.number, got.MinimaxScore, got.OptimumScore.Score, tt.keepPar)
+			}
+		})
+	}
+
+	// rotating first moves par along with vulnerability, so renumbering only drops it if vulnerability differs
+	rotate, _ := Rotate(90)
+	got := Chain(rotate, Renumber(3))(parBoard())
+	if got.MinimaxScore.Direction != pbn.East || got.OptimumScore.Score != -400 {
+		t.Errorf("Rotate(90) then Renumber(3) par = %+v, optimum %d, want the rotated par", got.MinimaxScore, got.OptimumScore.Score)
+	}
+}
@@ -0,0 +1,98 @@
+package deal
+
+import (
+	"errors"
+	"fmt"
+	"github.com/fe-dox/go-pbn"
+)
+
+var (
+	ErrEmptyDeal             = errors.New("deal is empty")
+	ErrInvalidHandSize       = errors.New("hand does not have 13 cards")
+	ErrInvalidCard           = errors.New("invalid card")
+	ErrDuplicateCard         = errors.New("card is dealt more than once")
+	ErrInvalidTricks         = errors.New("double dummy tricks out of range")
+	ErrMissingDoubleDummy    = errors.New("double dummy tricks are missing")
+	ErrDealerMismatch        = errors.New("dealer does not match board number")
+	ErrVulnerabilityMismatch = errors.New("vulnerability does not match board number")
+)
+
+var directions = []pbn.Direction{pbn.North, pbn.East, pbn.South, pbn.West}
+
+var suits = []pbn.Suit{pbn.Spades, pbn.Hearts, pbn.Diamonds, pbn.Clubs}
+
+var denominations = []pbn.Suit{pbn.NoTrump, pbn.Spades, pbn.Hearts, pbn.Diamonds, pbn.Clubs}
+
+// Validate checks that board holds a complete deal with sensible double dummy data. Problems which do not make
+// the deal unusable are reported too, IsWarning tells them apart.
+func Validate(board pbn.Board) []error {
+	var problems []error
+	if IsEmpty(board) {
+		return []error{ErrEmptyDeal}
+	}
+	seen := make(map[pbn.Suit]map[pbn.CardValue]pbn.Direction, 4)
+	for _, suit := range suits {
+		seen[suit] = make(map[pbn.CardValue]pbn.Direction, 13)
+	}
+	for _, direction := range directions {
+		hand := board.Hands[direction]
+		var size int
+		for _, suit := range suits {
+			for _, card := range hand[suit] {
+				size++
+				if card < pbn.A || card > pbn.K {
+					problems = append(problems, fmt.Errorf("%w: %s holds %s%s", ErrInvalidCard, direction, suit, card))
+					continue
+				}
+				if holder, ok := seen[suit][card]; ok {
+					problems = append(problems, fmt.Errorf("%w: %s%s held by %s and %s", ErrDuplicateCard, suit, card, holder, direction))
+					continue
+				}
+				seen[suit][card] = direction
+			}
+		}
+		if size != 13 {
+			problems = append(problems, fmt.Errorf("%w: %s holds %d", ErrInvalidHandSize, direction, size))
+		}
+	}
+	if board.Ability == nil {
+		problems = append(problems, ErrMissingDoubleDummy)
+	} else {
+		for _, direction := range directions {
+			for _, suit := range denominations {
+				tricks, ok := board.Ability[direction][suit]
+				if !ok || tricks < 0 || tricks > 13 {
+					problems = append(problems, fmt.Errorf("%w: %s in %s", ErrInvalidTricks, direction, suit))
+				}
+			}
+		}
+	}
+	if board.Number > 0 {
+		if board.Dealer != pbn.DealerFromBoardNumber(board.Number) {
+			problems = append(problems, fmt.Errorf("%w: %s deals board %d", ErrDealerMismatch, board.Dealer, board.Number))
+		}
+		if board.Vulnerable != VulnerabilityFromBoardNumber(board.Number) {
+			problems = append(problems, fmt.Errorf("%w: %s on board %d", ErrVulnerabilityMismatch, board.Vulnerable, board.Number))
+		}
+	}
+	return problems
+}
+
+// IsWarning reports problems which are common in real tournaments (boards played out of their usual
+// rotation, no double dummy analysis) and therefore do not fail validation unless asked to.
+func IsWarning(err error) bool {
+	return errors.Is(err, ErrMissingDoubleDummy) ||
+		errors.Is(err, ErrDealerMismatch) ||
+		errors.Is(err, ErrVulnerabilityMismatch)
+}
+
+func IsEmpty(board pbn.Board) bool {
+	for _, hand := range board.Hands {
+		for _, cards := range hand {
+			if len(cards) > 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,70 @@
+package deal
+
+import (
+	"errors"
+	"github.com/fe-dox/go-pbn"
+	"testing"
+)
+
+func testBoard() pbn.Board {
+	return pbn.Board{
+		Number:     1,
+		Dealer:     pbn.North,
+		Vulnerable: pbn.None,
+		Hands: map[pbn.Direction]pbn.Hand{
+			pbn.North: {pbn.Spades: {pbn.A, pbn.K, 5}, pbn.Hearts: {pbn.Q, 7, 2}, pbn.Diamonds: {pbn.A, 9, 8, 4}, pbn.Clubs: {pbn.J, 6, 3}},
+			pbn.East:  {pbn.Spades: {pbn.Q, pbn.J, pbn.T}, pbn.Hearts: {pbn.A, pbn.K, 8}, pbn.Diamonds: {7, 6, 5}, pbn.Clubs: {pbn.K, 9, 8, 2}},
+			pbn.South: {pbn.Spades: {9, 8, 7, 6}, pbn.Hearts: {pbn.J, pbn.T, 9}, pbn.Diamonds: {pbn.K, pbn.Q}, pbn.Clubs: {pbn.A, pbn.Q, pbn.T, 4}},
+			pbn.West:  {pbn.Spades: {4, 3, 2}, pbn.Hearts: {6, 5, 4, 3}, pbn.Diamonds: {pbn.J, pbn.T, 3, 2}, pbn.Clubs: {7, 5}},
+		},
+		Ability: pbn.Ability{
+			pbn.North: {pbn.NoTrump: 9, pbn.Spades: 8, pbn.Hearts: 7, pbn.Diamonds: 8, pbn.Clubs: 9},
+			pbn.East:  {pbn.NoTrump: 4, pbn.Spades: 5, pbn.Hearts: 6, pbn.Diamonds: 5, pbn.Clubs: 4},
+			pbn.South: {pbn.NoTrump: 9, pbn.Spades: 8, pbn.Hearts: 7, pbn.Diamonds: 8, pbn.Clubs: 9},
+			pbn.West:  {pbn.NoTrump: 4, pbn.Spades: 5, pbn.Hearts: 6, pbn.Diamonds: 5, pbn.Clubs: 4},
+		},
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(b *pbn.Board)
+		want   []error
+	}{
+		{name: "valid", modify: func(b *pbn.Board) {}},
+		{name: "empty", modify: func(b *pbn.Board) { b.Hands = nil }, want: []error{ErrEmptyDeal}},
+		{
+			name: "duplicate card",
+			modify: func(b *pbn.Board) {
+				b.Hands[pbn.West][pbn.Clubs] = []pbn.CardValue{7, 6}
+			},
+			want: []error{ErrDuplicateCard},
+		},
+		{
+			name: "short hand",
+			modify: func(b *pbn.Board) {
+				b.Hands[pbn.West][pbn.Clubs] = []pbn.CardValue{7}
+			},
+			want: []error{ErrInvalidHandSize},
+		},
+		{name: "missing double dummy", modify: func(b *pbn.Board) { b.Ability = nil }, want: []error{ErrMissingDoubleDummy}},
+		{name: "wrong dealer", modify: func(b *pbn.Board) { b.Dealer = pbn.East }, want: []error{ErrDealerMismatch}},
+		{name: "wrong vulnerability", modify: func(b *pbn.Board) { b.Number = 5; b.Dealer = pbn.North }, want: []error{ErrVulnerabilityMismatch}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			board := testBoard()
+			tt.modify(&board)
+			got := Validate(board)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Validate() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if !errors.Is(got[i], tt.want[i]) {
+					t.Errorf("Validate()[%d] = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,228 @@
+package export
+
+import (
+	"github.com/fe-dox/go-pbn"
+	"strconv"
+	"strings"
+)
+
+// SchemaVersion is bumped whenever a field of Board or Document changes meaning or is removed.
+const SchemaVersion = 1
+
+type Source struct {
+	Url   string `json:"url"`
+	Board int    `json:"board"`
+}
+
+type Hand struct {
+	Spades   string `json:"spades"`
+	Hearts   string `json:"hearts"`
+	Diamonds string `json:"diamonds"`
+	Clubs    string `json:"clubs"`
+}
+
+type Hands struct {
+	North Hand `json:"N"`
+	East  Hand `json:"E"`
+	South Hand `json:"S"`
+	West  Hand `json:"W"`
+}
+
+type DenominationTricks struct {
+	NoTrump  int `json:"NT"`
+	Spades   int `json:"S"`
+	Hearts   int `json:"H"`
+	Diamonds int `json:"D"`
+	Clubs    int `json:"C"`
+}
+
+type Tricks struct {
+	North DenominationTricks `json:"N"`
+	East  DenominationTricks `json:"E"`
+	South DenominationTricks `json:"S"`
+	West  DenominationTricks `json:"W"`
+}
+
+type Par struct {
+	Contract string `json:"contract"`
+	Doubled  bool   `json:"doubled"`
+	Declarer string `json:"declarer"`
+	Score    int    `json:"score"`
+}
+
+type Board struct {
+	SchemaVersion  int     `json:"schemaVersion"`
+	Board          int     `json:"board"`
+	Source         Source  `json:"source"`
+	EventName      string  `json:"event,omitempty"`
+	Dealer         string  `json:"dealer"`
+	Vulnerability  string  `json:"vulnerability"`
+	Hands          Hands   `json:"hands"`
+	DoubleDummy    *Tricks `json:"doubleDummy,omitempty"`
+	Par            *Par    `json:"par,omitempty"`
+	OptimumScoreNS *int    `json:"optimumScoreNS,omitempty"`
+}
+
+type Document struct {
+	SchemaVersion int     `json:"schemaVersion"`
+	EventName     string  `json:"event"`
+	Generator     string  `json:"generator"`
+	Boards        []Board `json:"boards"`
+}
+
+// NewBoard converts board to the export schema. Source.Board is the board number used by TC,
+// whereas Board.Board is the number the board was played as.
+func NewBoard(board pbn.Board, source Source) Board {
+	b := Board{
+		SchemaVersion: SchemaVersion,
+		Board:         board.Number,
+		Source:        source,
+		EventName:     board.EventName,
+		Dealer:        board.Dealer.String(),
+		Vulnerability: board.Vulnerable.String(),
+		Hands: Hands{
+			North: newHand(board.Hands[pbn.North]),
+			East:  newHand(board.Hands[pbn.East]),
+			South: newHand(board.Hands[pbn.South]),
+			West:  newHand(board.Hands[pbn.West]),
+		},
+	}
+	if board.Ability != nil {
+		b.DoubleDummy = &Tricks{
+			North: newDenominationTricks(board.Ability[pbn.North]),
+			East:  newDenominationTricks(board.Ability[pbn.East]),
+			South: newDenominationTricks(board.Ability[pbn.South]),
+			West:  newDenominationTricks(board.Ability[pbn.West]),
+		}
+	}
+	if board.MinimaxScore.Level != 0 {
+		b.Par = &Par{
+			Contract: ContractString(board.MinimaxScore),
+			Doubled:  board.MinimaxScore.Doubled,
+			Declarer: board.MinimaxScore.Direction.String(),
+			Score:    board.MinimaxScore.Score,
+		}
+		score := board.OptimumScore.Score
+		b.OptimumScoreNS = &score
+	}
+	return b
+}
+
+func NewDocument(eventName string, generator string, boards []Board) Document {
+	if boards == nil {
+		boards = make([]Board, 0)
+	}
+	return Document{
+		SchemaVersion: SchemaVersion,
+		EventName:     eventName,
+		Generator:     generator,
+		Boards:        boards,
+	}
+}
+
+// ContractString formats contract as level and denomination, e.g. 4S, 3NT or 5HX.
+func ContractString(contract pbn.Contract) string {
+	s := strconv.Itoa(contract.Level) + contract.Suit.String()
+	if contract.Redoubled {
+		return s + "XX"
+	}
+	if contract.Doubled {
+		return s + "X"
+	}
+	return s
+}
+
+func newHand(hand pbn.Hand) Hand {
+	return Hand{
+		Spades:   suitString(hand[pbn.Spades]),
+		Hearts:   suitString(hand[pbn.Hearts]),
+		Diamonds: suitString(hand[pbn.Diamonds]),
+		Clubs:    suitString(hand[pbn.Clubs]),
+	}
+}
+
+func suitString(cards []pbn.CardValue) string {
+	var sb strings.Builder
+	for _, card := range cards {
+		sb.WriteString(card.String())
+	}
+	return sb.String()
+}
+
+func newDenominationTricks(tricks map[pbn.Suit]int) DenominationTricks {
+	return DenominationTricks{
+		NoTrump:  tricks[pbn.NoTrump],
+		Spades:   tricks[pbn.Spades],
+		Hearts:   tricks[pbn.Hearts],
+		Diamonds: tricks[pbn.Diamonds],
+		Clubs:    tricks[pbn.Clubs],
+	}
+}
+
+// PBN converts the exported board back to a pbn.Board, so boards stored in the schema can be rendered or
+// serialised again.
+func (b Board) PBN() pbn.Board {
+	board := pbn.Board{
+		Number:     b.Board,
+		Dealer:     pbn.DirectionFromString(b.Dealer),
+		Vulnerable: pbn.VulnerabilityFromString(b.Vulnerability),
+		EventName:  b.EventName,
+		Hands: map[pbn.Direction]pbn.Hand{
+			pbn.North: b.Hands.North.pbn(),
+			pbn.East:  b.Hands.East.pbn(),
+			pbn.South: b.Hands.South.pbn(),
+			pbn.West:  b.Hands.West.pbn(),
+		},
+	}
+	if b.DoubleDummy != nil {
+		board.Ability = pbn.Ability{
+			pbn.North: b.DoubleDummy.North.pbn(),
+			pbn.East:  b.DoubleDummy.East.pbn(),
+			pbn.South: b.DoubleDummy.South.pbn(),
+			pbn.West:  b.DoubleDummy.West.pbn(),
+		}
+	}
+	if b.Par != nil {
+		contract := strings.TrimSuffix(strings.TrimSuffix(b.Par.Contract, "X"), "X")
+		board.MinimaxScore = pbn.Contract{
+			Suit:      pbn.SuitFromSting(strings.TrimLeft(contract, "0123456789")),
+			Doubled:   b.Par.Doubled,
+			Redoubled: strings.HasSuffix(b.Par.Contract, "XX"),
+			Direction: pbn.DirectionFromString(b.Par.Declarer),
+			Score:     b.Par.Score,
+		}
+		board.MinimaxScore.Level, _ = strconv.Atoi(strings.TrimRight(contract, "NTSHDC"))
+		board.OptimumScore.Direction = pbn.North
+	}
+	if b.OptimumScoreNS != nil {
+		board.OptimumScore.Score = *b.OptimumScoreNS
+	}
+	return board
+}
+
+func (h Hand) pbn() pbn.Hand {
+	return pbn.Hand{
+		pbn.Spades:   parseSuit(h.Spades),
+		pbn.Hearts:   parseSuit(h.Hearts),
+		pbn.Diamonds: parseSuit(h.Diamonds),
+		pbn.Clubs:    parseSuit(h.Clubs),
+	}
+}
+
+func parseSuit(str string) []pbn.CardValue {
+	cards := make([]pbn.CardValue, 0, len(str))
+	for _, c := range str {
+		cards = append(cards, pbn.CardValueFromRune(c))
+	}
+	return cards
+}
+
+func (t DenominationTricks) pbn() map[pbn.Suit]int {
+	return map[pbn.Suit]int{
+		pbn.NoTrump:  t.NoTrump,
+		pbn.Spades:   t.Spades,
+		pbn.Hearts:   t.Hearts,
+		pbn.Diamonds: t.Diamonds,
+		pbn.Clubs:    t.Clubs,
+	}
+}
@@ -0,0 +1,127 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/fe-dox/go-pbn"
+	"reflect"
+	"testing"
+)
+
+func testBoard() pbn.Board {
+	return pbn.Board{
+		Number:     3,
+		Dealer:     pbn.South,
+		Vulnerable: pbn.EastWest,
+		Hands: map[pbn.Direction]pbn.Hand{
+			pbn.North: {pbn.Spades: {pbn.A, pbn.K, 5}, pbn.Hearts: {pbn.Q, 7, 2}, pbn.Diamonds: {pbn.A, 9, 8, 4}, pbn.Clubs: {pbn.J, 6, 3}},
+			pbn.East:  {pbn.Spades: {pbn.Q, pbn.J, 10}, pbn.Hearts: {pbn.A, pbn.K, 8}, pbn.Diamonds: {7, 6, 5}, pbn.Clubs: {pbn.K, 9, 8, 2}},
+			pbn.South: {pbn.Spades: {9, 8, 7, 6}, pbn.Hearts: {pbn.J, 10, 9}, pbn.Diamonds: {pbn.K, pbn.Q}, pbn.Clubs: {pbn.A, pbn.Q, 10, 4}},
+			pbn.West:  {pbn.Spades: {4, 3, 2}, pbn.Hearts: {6, 5, 4, 3}, pbn.Diamonds: {pbn.J, 10, 3, 2}, pbn.Clubs: {7, 5}},
+		},
+		Ability: pbn.Ability{
+			pbn.North: {pbn.NoTrump: 9, pbn.Spades: 8, pbn.Hearts: 7, pbn.Diamonds: 8, pbn.Clubs: 9},
+			pbn.East:  {pbn.NoTrump: 4, pbn.Spades: 5, pbn.Hearts: 6, pbn.Diamonds: 5, pbn.Clubs: 4},
+			pbn.South: {pbn.NoTrump: 9, pbn.Spades: 8, pbn.Hearts: 7, pbn.Diamonds: 8, pbn.Clubs: 9},
+			pbn.West:  {pbn.NoTrump: 4, pbn.Spades: 5, pbn.Hearts: 6, pbn.Diamonds: 5, pbn.Clubs: 4},
+		},
+		OptimumScore: struct {
+			Direction pbn.Direction
+			Score     int
+		}{Direction: pbn.North, Score: 400},
+		MinimaxScore: pbn.Contract{Level: 3, Suit: pbn.NoTrump, Direction: pbn.North, Score: 400},
+	}
+}
+
+func TestContractString(t *testing.T) {
+	tests := []struct {
+		name     string
+		contract pbn.Contract
+		want     string
+	}{
+		{name: "no trump", contract: pbn.Contract{Level: 3, Suit: pbn.NoTrump}, want: "3NT"},
+		{name: "suit", contract: pbn.Contract{Level: 4, Suit: pbn.Spades}, want: "4S"},
+		{name: "doubled", contract: pbn.Contract{Level: 5, Suit: pbn.Hearts, Doubled: true}, want: "5HX"},
+		{name: "redoubled", contract: pbn.Contract{Level: 2, Suit: pbn.Clubs, Doubled: true, Redoubled: true}, want: "2CXX"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ContractString(tt.contract); got != tt.want {
+				t.Errorf("ContractString() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNDJSONWriter(t *testing.T) {
+	var b bytes.Buffer
+	w, err := NewWriter(FormatNDJSON, &b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		err = w.WriteBoard(testBoard(), Source{Url: "https://example.com/t/", Board: 27})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	lines := bytes.Split(bytes.TrimSpace(b.Bytes()), []byte("\n"))
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d", len(lines))
+	}
+	var got Board
+	if err = json.Unmarshal(lines[0], &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.SchemaVersion != SchemaVersion || got.Board != 3 || got.Source.Board != 27 {
+		t.Errorf("unexpected board metadata %+v", got)
+	}
+	if got.Dealer != "S" || got.Vulnerability != "EW" {
+		t.Errorf("unexpected dealer or vulnerability %s %s", got.Dealer, got.Vulnerability)
+	}
+	if got.Hands.South.Hearts != "JT9" || got.Hands.East.Clubs != "K982" {
+		t.Errorf("unexpected hands %+v", got.Hands)
+	}
+	if got.DoubleDummy == nil || got.DoubleDummy.North.NoTrump != 9 {
+		t.Errorf("unexpected double dummy %+v", got.DoubleDummy)
+	}
+	if got.Par == nil || got.Par.Contract != "3NT" || got.Par.Declarer != "N" || *got.OptimumScoreNS != 400 {
+		t.Errorf("unexpected par %+v", got.Par)
+	}
+}
+
+func TestHighCardPointsAndShape(t *testing.T) {
+	board := testBoard()
+	tests := []struct {
+		direction pbn.Direction
+		hcp       int
+		shape     string
+	}{
+		{direction: pbn.North, hcp: 14, shape: "3-3-4-3"},
+		{direction: pbn.East, hcp: 13, shape: "3-3-3-4"},
+		{direction: pbn.South, hcp: 12, shape: "4-3-2-4"},
+		{direction: pbn.West, hcp: 1, shape: "3-4-4-2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.direction.String(), func(t *testing.T) {
+			if got := HighCardPoints(board.Hands[tt.direction]); got != tt.hcp {
+				t.Errorf("HighCardPoints() = %v, want %v", got, tt.hcp)
+			}
+			if got := Shape(board.Hands[tt.direction]); got != tt.shape {
+				t.Errorf("Shape() = %v, want %v", got, tt.shape)
+			}
+		})
+	}
+}
+
+func TestBoardPBNRoundTrip(t *testing.T) {
+	board := testBoard()
+	source := Source{Url: "https://example.com/t/", Board: 3}
+	exported := NewBoard(board, source)
+	if got := NewBoard(exported.PBN(), source); !reflect.DeepEqual(got, exported) {
+		t.Errorf("round trip got = %+v, want %+v", got, exported)
+	}
+}
@@ -0,0 +1,87 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"github.com/fe-dox/go-pbn"
+	"io"
+	"strconv"
+)
+
+var directions = []pbn.Direction{pbn.North, pbn.East, pbn.South, pbn.West}
+
+var denominations = []pbn.Suit{pbn.NoTrump, pbn.Spades, pbn.Hearts, pbn.Diamonds, pbn.Clubs}
+
+type csvWriter struct {
+	w             *csv.Writer
+	headerWritten bool
+}
+
+func csvHeader() []string {
+	header := []string{"board", "dealer", "vulnerability"}
+	for _, direction := range directions {
+		header = append(header, "hand_"+direction.String())
+	}
+	for _, direction := range directions {
+		header = append(header, "hcp_"+direction.String())
+	}
+	for _, direction := range directions {
+		header = append(header, "shape_"+direction.String())
+	}
+	for _, direction := range directions {
+		for _, suit := range denominations {
+			header = append(header, fmt.Sprintf("dd_%s_%s", direction, suit))
+		}
+	}
+	return append(header, "par_contract", "par_declarer", "par_score")
+}
+
+func (c *csvWriter) WriteBoard(board pbn.Board, _ Source) error {
+	if !c.headerWritten {
+		err := c.w.Write(csvHeader())
+		if err != nil {
+			return err
+		}
+		c.headerWritten = true
+	}
+	record := []string{strconv.Itoa(board.Number), board.Dealer.String(), board.Vulnerable.String()}
+	for _, direction := range directions {
+		hand := board.Hands[direction]
+		record = append(record, hand.String())
+	}
+	for _, direction := range directions {
+		record = append(record, strconv.Itoa(HighCardPoints(board.Hands[direction])))
+	}
+	for _, direction := range directions {
+		record = append(record, Shape(board.Hands[direction]))
+	}
+	for _, direction := range directions {
+		for _, suit := range denominations {
+			if board.Ability == nil {
+				record = append(record, "")
+				continue
+			}
+			record = append(record, strconv.Itoa(board.Ability[direction][suit]))
+		}
+	}
+	if board.MinimaxScore.Level != 0 {
+		record = append(record, ContractString(board.MinimaxScore), board.MinimaxScore.Direction.String(), strconv.Itoa(board.OptimumScore.Score))
+	} else {
+		record = append(record, "", "", "")
+	}
+	err := c.w.Write(record)
+	if err != nil {
+		return err
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *csvWriter) Flush() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func newCsvWriter(w io.Writer) *csvWriter {
+	return &csvWriter{w: csv.NewWriter(w)}
+}
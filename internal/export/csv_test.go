@@ -0,0 +1,82 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"github.com/fe-dox/go-pbn"
+	"reflect"
+	"testing"
+)
+
+func TestCsvWriter(t *testing.T) {
+	var b bytes.Buffer
+	w, err := NewWriter(FormatCSV, &b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, board := range []pbn.Board{testBoard(), voidBoard()} {
+		if err = w.WriteBoard(board, Source{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&b).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("csv has %d records, want a header and 2 boards", len(records))
+	}
+	if !reflect.DeepEqual(records[0], csvHeader()) {
+		t.Errorf("csv header = %v, want %v", records[0], csvHeader())
+	}
+	if len(records[0]) != 3+4+4+4+20+3 {
+		t.Errorf("csv header has %d columns, want 38", len(records[0]))
+	}
+
+	tests := []struct {
+		name   string
+		record []string
+		want   []string
+	}{
+		{
+			name:   "with double dummy",
+			record: records[1],
+			want: []string{
+				"3", "S", "EW",
+				"AK5.Q72.A984.J63", "QJT.AK8.765.K982", "9876.JT9.KQ.AQT4", "432.6543.JT32.75",
+				"14", "13", "12", "1",
+				"3-3-4-3", "3-3-3-4", "4-3-2-4", "3-4-4-2",
+				"9", "8", "7", "8", "9",
+				"4", "5", "6", "5", "4",
+				"9", "8", "7", "8", "9",
+				"4", "5", "6", "5", "4",
+				"3NT", "N", "400",
+			},
+		},
+		{
+			name:   "without double dummy",
+			record: records[2],
+			want: []string{
+				"3", "S", "EW",
+				"AK5432.Q72.A984.", "QJT.AK8.765.K982", "9876.JT9.KQ.AQT4", "432.6543.JT32.75",
+				"13", "13", "12", "1",
+				"6-3-4-0", "3-3-3-4", "4-3-2-4", "3-4-4-2",
+				"", "", "", "", "",
+				"", "", "", "", "",
+				"", "", "", "", "",
+				"", "", "", "", "",
+				"", "", "",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !reflect.DeepEqual(tt.record, tt.want) {
+				t.Errorf("csv row = %q, want %q", tt.record, tt.want)
+			}
+		})
+	}
+}
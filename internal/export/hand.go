@@ -0,0 +1,47 @@
+package export
+
+import (
+	"fmt"
+	"github.com/fe-dox/go-pbn"
+	"strings"
+)
+
+func HighCardPoints(hand pbn.Hand) int {
+	var hcp int
+	for _, cards := range hand {
+		for _, card := range cards {
+			switch card {
+			case pbn.A:
+				hcp += 4
+			case pbn.K:
+				hcp += 3
+			case pbn.Q:
+				hcp += 2
+			case pbn.J:
+				hcp += 1
+			}
+		}
+	}
+	return hcp
+}
+
+// Shape returns suit lengths in spades, hearts, diamonds, clubs order, e.g. 4-3-3-3.
+func Shape(hand pbn.Hand) string {
+	return fmt.Sprintf("%d-%d-%d-%d", len(hand[pbn.Spades]), len(hand[pbn.Hearts]), len(hand[pbn.Diamonds]), len(hand[pbn.Clubs]))
+}
+
+// DisplayCards formats cards of a suit for people rather than PBN, with 10 instead of T and a dash for a void.
+func DisplayCards(cards []pbn.CardValue) string {
+	if len(cards) == 0 {
+		return "—"
+	}
+	var sb strings.Builder
+	for _, card := range cards {
+		if card == pbn.T {
+			sb.WriteString("10")
+			continue
+		}
+		sb.WriteString(card.String())
+	}
+	return sb.String()
+}
@@ -0,0 +1,138 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/fe-dox/go-pbn"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+var suitSymbols = map[pbn.Suit]string{
+	pbn.NoTrump:  "NT",
+	pbn.Spades:   "♠",
+	pbn.Hearts:   "♥",
+	pbn.Diamonds: "♦",
+	pbn.Clubs:    "♣",
+}
+
+var doubleDummyOrder = []pbn.Direction{pbn.North, pbn.South, pbn.East, pbn.West}
+
+type textWriter struct {
+	w        *bufio.Writer
+	markdown bool
+}
+
+func (t *textWriter) WriteBoard(board pbn.Board, _ Source) error {
+	if t.markdown {
+		fmt.Fprintf(t.w, "### Board %d\n\n```\n", board.Number)
+	} else {
+		fmt.Fprintf(t.w, "Board %d\n\n", board.Number)
+	}
+	for _, line := range compassDiagram(board) {
+		fmt.Fprintln(t.w, strings.TrimRight(line, " "))
+	}
+	if t.markdown {
+		fmt.Fprint(t.w, "```\n")
+	}
+	fmt.Fprintf(t.w, "\nDealer: %s, Vulnerable: %s\n", board.Dealer, board.Vulnerable)
+	if board.Ability != nil {
+		fmt.Fprintln(t.w)
+		if t.markdown {
+			writeMarkdownDoubleDummy(t.w, board.Ability)
+		} else {
+			writeTextDoubleDummy(t.w, board.Ability)
+		}
+	}
+	if board.MinimaxScore.Level != 0 {
+		fmt.Fprintf(t.w, "\nPar: %s by %s, NS %+d\n", ContractString(board.MinimaxScore), board.MinimaxScore.Direction, board.OptimumScore.Score)
+	}
+	fmt.Fprintln(t.w)
+	return t.w.Flush()
+}
+
+func (t *textWriter) Flush() error {
+	return t.w.Flush()
+}
+
+// compassDiagram lays the hands out the way bridge columns do: North on top, West and East side by side, South below.
+func compassDiagram(board pbn.Board) []string {
+	hands := make(map[pbn.Direction][]string, 4)
+	for _, direction := range directions {
+		lines := make([]string, 0, 4)
+		hand := board.Hands[direction]
+		for _, suit := range []pbn.Suit{pbn.Spades, pbn.Hearts, pbn.Diamonds, pbn.Clubs} {
+			lines = append(lines, suitSymbols[suit]+" "+DisplayCards(hand[suit]))
+		}
+		hands[direction] = lines
+	}
+	left := maxInt(maxWidth(hands[pbn.West])+3, 12)
+	middle := maxInt(maxInt(maxWidth(hands[pbn.North]), maxWidth(hands[pbn.South])), 10) + 3
+	indent := strings.Repeat(" ", left)
+
+	lines := make([]string, 0, 12)
+	for _, line := range hands[pbn.North] {
+		lines = append(lines, indent+line)
+	}
+	for i := range hands[pbn.West] {
+		lines = append(lines, pad(hands[pbn.West][i], left+middle)+hands[pbn.East][i])
+	}
+	for _, line := range hands[pbn.South] {
+		lines = append(lines, indent+line)
+	}
+	return lines
+}
+
+func writeTextDoubleDummy(w io.Writer, ability pbn.Ability) {
+	fmt.Fprint(w, "  ")
+	for _, suit := range denominations {
+		fmt.Fprintf(w, "%4s", suitSymbols[suit])
+	}
+	fmt.Fprintln(w)
+	for _, direction := range doubleDummyOrder {
+		fmt.Fprintf(w, "%-2s", direction)
+		for _, suit := range denominations {
+			fmt.Fprintf(w, "%4d", ability[direction][suit])
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+func writeMarkdownDoubleDummy(w io.Writer, ability pbn.Ability) {
+	fmt.Fprint(w, "|   |")
+	for _, suit := range denominations {
+		fmt.Fprintf(w, " %s |", suitSymbols[suit])
+	}
+	fmt.Fprint(w, "\n|---|")
+	for range denominations {
+		fmt.Fprint(w, "---|")
+	}
+	fmt.Fprintln(w)
+	for _, direction := range doubleDummyOrder {
+		fmt.Fprintf(w, "| %s |", direction)
+		for _, suit := range denominations {
+			fmt.Fprintf(w, " %d |", ability[direction][suit])
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+func maxWidth(lines []string) int {
+	var width int
+	for _, line := range lines {
+		width = maxInt(width, utf8.RuneCountInString(line))
+	}
+	return width
+}
+
+func pad(str string, width int) string {
+	return str + strings.Repeat(" ", maxInt(width-utf8.RuneCountInString(str), 1))
+}
+
+func maxInt(a int, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,164 @@
+package export
+
+import (
+	"bytes"
+	"github.com/fe-dox/go-pbn"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testDiagram = `            ♠ AK5
+            ♥ Q72
+            ♦ A984
+            ♣ J63
+♠ 432                    ♠ QJ10
+♥ 6543                   ♥ AK8
+♦ J1032                  ♦ 765
+♣ 75                     ♣ K982
+            ♠ 9876
+            ♥ J109
+            ♦ KQ
+            ♣ AQ104
+`
+
+const voidDiagram = `            ♠ AK5432
+            ♥ Q72
+            ♦ A984
+            ♣ —
+♠ 432                    ♠ QJ10
+♥ 6543                   ♥ AK8
+♦ J1032                  ♦ 765
+♣ 75                     ♣ K982
+            ♠ 9876
+            ♥ J109
+            ♦ KQ
+            ♣ AQ104
+`
+
+// voidBoard is testBoard with the clubs of North moved to spades and neither double dummy analysis nor par.
+func voidBoard() pbn.Board {
+	board := testBoard()
+	board.Hands[pbn.North] = pbn.Hand{pbn.Spades: {pbn.A, pbn.K, 5, 4, 3, 2}, pbn.Hearts: {pbn.Q, 7, 2}, pbn.Diamonds: {pbn.A, 9, 8, 4}}
+	board.Ability = nil
+	board.MinimaxScore = pbn.Contract{}
+	return board
+}
+
+func TestTextWriter(t *testing.T) {
+	tests := []struct {
+		name   string
+		format Format
+		board  pbn.Board
+		want   string
+	}{
+		{
+			name:   "text",
+			format: FormatText,
+			board:  testBoard(),
+			want: "Board 3\n\n" + testDiagram + `
+Dealer: S, Vulnerable: EW
+
+    NT   ♠   ♥   ♦   ♣
+N    9   8   7   8   9
+S    9   8   7   8   9
+E    4   5   6   5   4
+W    4   5   6   5   4
+
+Par: 3NT by N, NS +400
+
+`,
+		},
+		{
+			name:   "text without double dummy",
+			format: FormatText,
+			board:  voidBoard(),
+			want:   "Board 3\n\n" + voidDiagram + "\nDealer: S, Vulnerable: EW\n\n",
+		},
+		{
+			name:   "markdown",
+			format: FormatMarkdown,
+			board:  testBoard(),
+			want: "### Board 3\n\n```\n" + testDiagram + "```\n" + `
+Dealer: S, Vulnerable: EW
+
+|   | NT | ♠ | ♥ | ♦ | ♣ |
+|---|---|---|---|---|---|
+| N | 9 | 8 | 7 | 8 | 9 |
+| S | 9 | 8 | 7 | 8 | 9 |
+| E | 4 | 5 | 6 | 5 | 4 |
+| W | 4 | 5 | 6 | 5 | 4 |
+
+Par: 3NT by N, NS +400
+
+`,
+		},
+		{
+			name:   "markdown without double dummy",
+			format: FormatMarkdown,
+			board:  voidBoard(),
+			want:   "### Board 3\n\n```\n" + voidDiagram + "```\n\nDealer: S, Vulnerable: EW\n\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b bytes.Buffer
+			w, err := NewWriter(tt.format, &b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err = w.WriteBoard(tt.board, Source{}); err != nil {
+				t.Fatal(err)
+			}
+			if err = w.Flush(); err != nil {
+				t.Fatal(err)
+			}
+			if got := b.String(); got != tt.want {
+				t.Errorf("%s output =\n%s\nwant\n%s", tt.format, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompassDiagram(t *testing.T) {
+	long := testBoard()
+	long.Hands[pbn.West] = pbn.Hand{pbn.Spades: {pbn.K, pbn.J, 10, 9, 8, 7, 6, 5, 4, 3, 2}, pbn.Hearts: {6, 5}}
+	long.Hands[pbn.North] = pbn.Hand{}
+	tests := []struct {
+		name  string
+		board pbn.Board
+		want  string
+	}{
+		{name: "full deal", board: testBoard(), want: testDiagram},
+		{name: "void", board: voidBoard(), want: voidDiagram},
+		{
+			// a long West suit pushes North, South and East to the right, voids keep a placeholder
+			name:  "long suit",
+			board: long,
+			want: `                 ♠ —
+                 ♥ —
+                 ♦ —
+                 ♣ —
+♠ KJ1098765432                ♠ QJ10
+♥ 65                          ♥ AK8
+♦ —                           ♦ 765
+♣ —                           ♣ K982
+                 ♠ 9876
+                 ♥ J109
+                 ♦ KQ
+                 ♣ AQ104
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, line := range compassDiagram(tt.board) {
+				got = append(got, strings.TrimRight(line, " "))
+			}
+			if want := strings.Split(strings.TrimSuffix(tt.want, "\n"), "\n"); !reflect.DeepEqual(got, want) {
+				t.Errorf("compassDiagram() =\n%s\nwant\n%s", strings.Join(got, "\n"), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"github.com/fe-dox/go-pbn"
+	"io"
+	"strings"
+)
+
+type Format string
+
+const (
+	FormatPBN      Format = "pbn"
+	FormatJSON     Format = "json"
+	FormatNDJSON   Format = "ndjson"
+	FormatCSV      Format = "csv"
+	FormatText     Format = "text"
+	FormatMarkdown Format = "markdown"
+)
+
+var ErrUnknownFormat = errors.New("unknown output format")
+
+var Formats = []Format{FormatPBN, FormatJSON, FormatNDJSON, FormatCSV, FormatText, FormatMarkdown}
+
+func (f Format) Extension() string {
+	switch f {
+	case FormatText:
+		return "txt"
+	case FormatMarkdown:
+		return "md"
+	default:
+		return string(f)
+	}
+}
+
+var fileNameReplacer = strings.NewReplacer("/", "_", "\\", "_", ":", "_", "*", "_", "?", "_", "\"", "_", "<", "_", ">", "_", "|", "_")
+
+// SafeFileName replaces characters that are not allowed in file names on common systems.
+func SafeFileName(name string) string {
+	return fileNameReplacer.Replace(strings.TrimSpace(name))
+}
+
+// Writer writes boards in a single output format. Flush must be called once all boards were written,
+// formats which need the whole board set (like JSON) write nothing before that.
+type Writer interface {
+	WriteBoard(board pbn.Board, source Source) error
+	Flush() error
+}
+
+func NewWriter(format Format, w io.Writer) (Writer, error) {
+	switch format {
+	case FormatPBN:
+		return &pbnWriter{w: w}, nil
+	case FormatJSON:
+		return &jsonWriter{w: w}, nil
+	case FormatNDJSON:
+		return &ndjsonWriter{enc: json.NewEncoder(w)}, nil
+	case FormatCSV:
+		return newCsvWriter(w), nil
+	case FormatText, FormatMarkdown:
+		return &textWriter{w: bufio.NewWriter(w), markdown: format == FormatMarkdown}, nil
+	default:
+		return nil, ErrUnknownFormat
+	}
+}
+
+type pbnWriter struct {
+	w io.Writer
+}
+
+func (p *pbnWriter) WriteBoard(board pbn.Board, _ Source) error {
+	return board.Serialize(p.w, true)
+}
+
+func (p *pbnWriter) Flush() error {
+	return nil
+}
+
+type jsonWriter struct {
+	w         io.Writer
+	eventName string
+	generator string
+	boards    []Board
+}
+
+func (j *jsonWriter) WriteBoard(board pbn.Board, source Source) error {
+	if len(j.boards) == 0 {
+		j.eventName = board.EventName
+		j.generator = board.Generator
+	}
+	j.boards = append(j.boards, NewBoard(board, source))
+	return nil
+}
+
+func (j *jsonWriter) Flush() error {
+	enc := json.NewEncoder(j.w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(NewDocument(j.eventName, j.generator, j.boards))
+}
+
+type ndjsonWriter struct {
+	enc *json.Encoder
+}
+
+func (n *ndjsonWriter) WriteBoard(board pbn.Board, source Source) error {
+	return n.enc.Encode(NewBoard(board, source))
+}
+
+func (n *ndjsonWriter) Flush() error {
+	return nil
+}
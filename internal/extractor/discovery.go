@@ -0,0 +1,86 @@
+package extractor
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+)
+
+var hrefPattern = regexp.MustCompile(`(?i)href\s*=\s*["']([^"'#?]+)`)
+
+// maxDiscoveryCandidates limits how many linked pages are probed for settings.json, so pointing list at a big
+// page does not hammer the site.
+const maxDiscoveryCandidates = 200
+
+type DiscoveredTournament struct {
+	Url      string
+	Settings TournamentSettings
+}
+
+// DiscoverTournaments looks for TC tournaments at pageUrl and in the directories of pages it links to on the
+// same host. A directory is a tournament if it serves settings.json.
+func (e *Extractor) DiscoverTournaments(pageUrl string) ([]DiscoveredTournament, error) {
+	base, err := url.Parse(pageUrl)
+	if err != nil {
+		return nil, err
+	}
+	response, err := e.get(pageUrl)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, ErrUnexpectedStatusCode
+	}
+	page, err := io.ReadAll(io.LimitReader(response.Body, 10<<20))
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := []string{tournamentDirectory(base)}
+	seen := map[string]bool{candidates[0]: true}
+	for _, match := range hrefPattern.FindAllStringSubmatch(string(page), -1) {
+		link, err := base.Parse(strings.TrimSpace(match[1]))
+		if err != nil || link.Host != base.Host || (link.Scheme != "http" && link.Scheme != "https") {
+			continue
+		}
+		candidate := tournamentDirectory(link)
+		if seen[candidate] {
+			continue
+		}
+		seen[candidate] = true
+		candidates = append(candidates, candidate)
+		if len(candidates) >= maxDiscoveryCandidates {
+			break
+		}
+	}
+
+	tournaments := make([]DiscoveredTournament, 0)
+	for _, candidate := range candidates {
+		settings, err := e.ExtractSettingsFromUrl(candidate)
+		if err != nil {
+			continue
+		}
+		tournaments = append(tournaments, DiscoveredTournament{Url: candidate, Settings: settings})
+	}
+	return tournaments, nil
+}
+
+// tournamentDirectory strips the page name from u, e.g. .../event/index.html becomes .../event/.
+func tournamentDirectory(u *url.URL) string {
+	dir := *u
+	dir.RawQuery = ""
+	dir.Fragment = ""
+	if !strings.HasSuffix(dir.Path, "/") {
+		if path.Ext(dir.Path) != "" {
+			dir.Path = path.Dir(dir.Path)
+		}
+		if !strings.HasSuffix(dir.Path, "/") {
+			dir.Path += "/"
+		}
+	}
+	return dir.String()
+}
@@ -1,9 +1,28 @@
 package extractor
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
 	ErrUnexpectedStatusCode = errors.New("unexpected status code")
 	ErrSettingsFileNotFound = errors.New("settings.json does not exist")
 	ErrNoDistributionData   = errors.New("no distribution data")
+	ErrNoBoards             = errors.New("tournament has no boards")
+	ErrNotModified          = errors.New("not modified")
 )
+
+// StatusCodeError is returned when TC answers with anything but 200, errors.Is(err, ErrUnexpectedStatusCode)
+// holds for it.
+type StatusCodeError struct {
+	StatusCode int
+}
+
+func (e StatusCodeError) Error() string {
+	return fmt.Sprintf("%v: %d", ErrUnexpectedStatusCode, e.StatusCode)
+}
+
+func (e StatusCodeError) Unwrap() error {
+	return ErrUnexpectedStatusCode
+}
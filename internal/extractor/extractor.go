@@ -1,6 +1,7 @@
 package extractor
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/fe-dox/go-pbn"
@@ -11,9 +12,14 @@ import (
 	"time"
 )
 
+const retryDelay = 500 * time.Millisecond
+
 type Extractor struct {
 	UserAgent string
 	client    http.Client
+	limiter   *rateLimiter
+	retries   int
+	ctx       context.Context
 }
 
 func NewExtractor(userAgent string, timeout time.Duration) *Extractor {
@@ -23,56 +29,182 @@ func NewExtractor(userAgent string, timeout time.Duration) *Extractor {
 	}
 }
 
-type RawTournamentSettings struct {
+// WithRateLimit makes the extractor wait at least interval between requests, across all goroutines using it.
+func (e *Extractor) WithRateLimit(interval time.Duration) *Extractor {
+	e.limiter = newRateLimiter(interval)
+	return e
+}
+
+// WithRetries makes the extractor repeat requests that failed on the network or with a 5xx or 429 status, up
+// to retries more times with a growing delay.
+func (e *Extractor) WithRetries(retries int) *Extractor {
+	e.retries = retries
+	return e
+}
+
+// WithContext returns a copy of the extractor whose requests, and delays between retries, end when ctx is done.
+// The copy shares the rate limit of the original.
+func (e *Extractor) WithContext(ctx context.Context) *Extractor {
+	c := *e
+	c.ctx = ctx
+	return &c
+}
+
+func (e *Extractor) context() context.Context {
+	if e.ctx == nil {
+		return context.Background()
+	}
+	return e.ctx
+}
+
+// FetchInfo describes how a file was downloaded from TC.
+type FetchInfo struct {
+	StatusCode int
+	Retries    int
+}
+
+func (e *Extractor) get(requestUrl string) (*http.Response, error) {
+	response, _, err := e.getIfModified(requestUrl, Validators{})
+	return response, err
+}
+
+// getIfModified sends a conditional request when validators are known, TC answers 304 if nothing changed.
+func (e *Extractor) getIfModified(requestUrl string, validators Validators) (*http.Response, FetchInfo, error) {
+	var info FetchInfo
+	for {
+		request, err := http.NewRequestWithContext(e.context(), "GET", requestUrl, nil)
+		if err != nil {
+			return nil, info, err
+		}
+		request.Header.Add("User-Agent", e.UserAgent)
+		if validators.ETag != "" {
+			request.Header.Add("If-None-Match", validators.ETag)
+		}
+		if validators.LastModified != "" {
+			request.Header.Add("If-Modified-Since", validators.LastModified)
+		}
+		err = e.limiter.wait(e.context())
+		if err != nil {
+			return nil, info, err
+		}
+		response, err := e.client.Do(request)
+		if err == nil {
+			info.StatusCode = response.StatusCode
+		}
+		if info.Retries >= e.retries || !shouldRetry(response, err) {
+			return response, info, err
+		}
+		if response != nil {
+			response.Body.Close()
+		}
+		info.Retries++
+		select {
+		case <-time.After(time.Duration(info.Retries) * retryDelay):
+		case <-e.context().Done():
+			return nil, info, e.context().Err()
+		}
+	}
+}
+
+func shouldRetry(response *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return response.StatusCode >= 500 || response.StatusCode == http.StatusTooManyRequests
+}
+
+// Validators are the cache validators of a TC file, sent back to only download it again when it changed.
+type Validators struct {
+	ETag         string
+	LastModified string
+}
+
+func validatorsFromResponse(response *http.Response) Validators {
+	return Validators{
+		ETag:         response.Header.Get("ETag"),
+		LastModified: response.Header.Get("Last-Modified"),
+	}
+}
+
+type RawSession struct {
+	Name          string `json:"Name"`
 	BoardsNumbers []int  `json:"BoardsNumbers"`
-	FullName      string `json:"FullName"`
+}
+
+type RawTournamentSettings struct {
+	BoardsNumbers []int        `json:"BoardsNumbers"`
+	FullName      string       `json:"FullName"`
+	Sessions      []RawSession `json:"Sessions"`
+}
+
+type Session struct {
+	Number        int
+	Name          string
+	BoardsNumbers []int
 }
 
 type TournamentSettings struct {
 	StartBoardNumber int
 	EndBoardNumber   int
 	EventName        string
+	BoardsNumbers    []int
+	Sessions         []Session
 }
 
 func (e *Extractor) ExtractSettingsFromUrl(baseUrl string) (TournamentSettings, error) {
+	settings, _, err := e.ExtractSettingsIfModified(baseUrl, Validators{})
+	return settings, err
+}
+
+// ExtractSettingsIfModified returns ErrNotModified when settings did not change since validators were received.
+func (e *Extractor) ExtractSettingsIfModified(baseUrl string, validators Validators) (TournamentSettings, Validators, error) {
 	settingsUrl, err := url.JoinPath(baseUrl, "settings.json")
 	if err != nil {
-		return TournamentSettings{}, err
+		return TournamentSettings{}, validators, err
 	}
 
-	request, err := http.NewRequest("GET", settingsUrl, nil)
+	response, _, err := e.getIfModified(settingsUrl, validators)
 	if err != nil {
-		return TournamentSettings{}, err
+		return TournamentSettings{}, validators, err
 	}
-
-	request.Header.Add("User-Agent", e.UserAgent)
-	response, err := e.client.Do(request)
-	if err != nil {
-		return TournamentSettings{}, err
+	defer response.Body.Close()
+	if response.StatusCode == http.StatusNotModified {
+		return TournamentSettings{}, validators, ErrNotModified
 	}
 	if response.StatusCode != http.StatusOK {
-		return TournamentSettings{}, ErrSettingsFileNotFound
+		return TournamentSettings{}, validators, ErrSettingsFileNotFound
 	}
-
-	defer response.Body.Close()
+	validators = validatorsFromResponse(response)
 
 	var data RawTournamentSettings
 	err = json.NewDecoder(response.Body).Decode(&data)
 	if err != nil {
-		return TournamentSettings{}, err
+		return TournamentSettings{}, validators, err
+	}
+	if len(data.BoardsNumbers) == 0 {
+		return TournamentSettings{}, validators, ErrNoBoards
 	}
 
 	ts := TournamentSettings{
 		StartBoardNumber: data.BoardsNumbers[0],
 		EndBoardNumber:   data.BoardsNumbers[len(data.BoardsNumbers)-1],
 		EventName:        data.FullName,
+		BoardsNumbers:    data.BoardsNumbers,
+		Sessions:         make([]Session, 0, len(data.Sessions)),
+	}
+	for i, session := range data.Sessions {
+		ts.Sessions = append(ts.Sessions, Session{
+			Number:        i + 1,
+			Name:          session.Name,
+			BoardsNumbers: session.BoardsNumbers,
+		})
 	}
-	return ts, nil
+	return ts, validators, nil
 }
 
 func (e *Extractor) ExtractFromUrl(url string, start int, end int) ([]pbn.Board, map[int]error) {
 	boards := make([]pbn.Board, 0, end-start+1)
-	var errors map[int]error
+	errors := make(map[int]error)
 	for i := start; i <= end; i++ {
 		tmpBoards, err := e.ExtractOneFromUrl(url, i)
 		if err != nil {
@@ -99,6 +231,14 @@ type RawBoardData struct {
 	Declarer      int    `json:"_declarer"`
 }
 
+func (d RawBoardData) HasHandRecord() bool {
+	return d.HandN != Hand{}
+}
+
+func (d RawBoardData) HasDoubleDummy() bool {
+	return d.TricksFromN != Tricks{} || d.TricksFromE != Tricks{} || d.TricksFromS != Tricks{} || d.TricksFromW != Tricks{}
+}
+
 type Hand struct {
 	Clubs    string `json:"Clubs"`
 	Diamonds string `json:"Diamonds"`
@@ -114,48 +254,95 @@ type Tricks struct {
 	Spades   int `json:"Spades"`
 }
 
+type RawPair struct {
+	Number  int      `json:"Number"`
+	Players []string `json:"Players"`
+}
+
+type RawResult struct {
+	PairNS   RawPair `json:"PairNS"`
+	PairEW   RawPair `json:"PairEW"`
+	Contract string  `json:"Contract"`
+	Declarer int     `json:"Declarer"`
+	Lead     string  `json:"Lead"`
+	Tricks   int     `json:"Tricks"`
+	ScoreNS  int     `json:"ScoreNS"`
+	ResultNS float64 `json:"ResultNS"`
+	ResultEW float64 `json:"ResultEW"`
+}
+
+type RawDistribution struct {
+	Number         int          `json:"Number"`
+	NumberAsPlayed int          `json:"_numberAsPlayed"`
+	BoardData      RawBoardData `json:"_handRecord"`
+}
+
+type RawScoringGroup struct {
+	Distribution RawDistribution `json:"Distribution"`
+	Results      []RawResult     `json:"Results"`
+}
+
 type RawProtocol struct {
-	ScoringGroups []struct {
-		Distribution struct {
-			Number         int          `json:"Number"`
-			NumberAsPlayed int          `json:"_numberAsPlayed"`
-			BoardData      RawBoardData `json:"_handRecord"`
-		} `json:"Distribution"`
-	} `json:"ScoringGroups"`
+	ScoringGroups []RawScoringGroup `json:"ScoringGroups"`
 }
 
 func (e *Extractor) ExtractOneFromUrl(baseUrl string, boardNumber int) ([]pbn.Board, error) {
-	settingsUrl, err := url.JoinPath(baseUrl, fmt.Sprintf("p%d.json", boardNumber))
-	var data RawProtocol
+	data, err := e.ExtractProtocolFromUrl(baseUrl, boardNumber)
 	if err != nil {
 		return nil, err
 	}
-	request, err := http.NewRequest("GET", settingsUrl, nil)
+	return BoardsFromProtocol(data)
+}
+
+func (e *Extractor) ExtractProtocolFromUrl(baseUrl string, boardNumber int) (RawProtocol, error) {
+	protocol, _, err := e.ExtractProtocolIfModified(baseUrl, boardNumber, Validators{})
+	return protocol, err
+}
+
+// ExtractProtocolWithInfo is ExtractProtocolFromUrl that also tells how the protocol was downloaded.
+func (e *Extractor) ExtractProtocolWithInfo(baseUrl string, boardNumber int) (RawProtocol, FetchInfo, error) {
+	protocol, _, info, err := e.extractProtocol(baseUrl, boardNumber, Validators{})
+	return protocol, info, err
+}
+
+// ExtractProtocolIfModified returns ErrNotModified when the protocol did not change since validators were received.
+func (e *Extractor) ExtractProtocolIfModified(baseUrl string, boardNumber int, validators Validators) (RawProtocol, Validators, error) {
+	protocol, validators, _, err := e.extractProtocol(baseUrl, boardNumber, validators)
+	return protocol, validators, err
+}
+
+func (e *Extractor) extractProtocol(baseUrl string, boardNumber int, validators Validators) (RawProtocol, Validators, FetchInfo, error) {
+	settingsUrl, err := url.JoinPath(baseUrl, fmt.Sprintf("p%d.json", boardNumber))
+	var data RawProtocol
 	if err != nil {
-		return nil, err
+		return RawProtocol{}, validators, FetchInfo{}, err
 	}
-	request.Header.Add("User-Agent", e.UserAgent)
-	response, err := e.client.Do(request)
+	response, info, err := e.getIfModified(settingsUrl, validators)
 	if err != nil {
-		return nil, err
+		return RawProtocol{}, validators, info, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode == http.StatusNotModified {
+		return RawProtocol{}, validators, info, ErrNotModified
 	}
 	if response.StatusCode != http.StatusOK {
-		return nil, ErrUnexpectedStatusCode
+		return RawProtocol{}, validators, info, StatusCodeError{StatusCode: response.StatusCode}
 	}
-	defer response.Body.Close()
+	validators = validatorsFromResponse(response)
 	err = json.NewDecoder(response.Body).Decode(&data)
 	if err != nil {
-		return nil, err
+		return RawProtocol{}, validators, info, err
 	}
+	return data, validators, info, nil
+}
+
+func BoardsFromProtocol(data RawProtocol) ([]pbn.Board, error) {
 	boards := make([]pbn.Board, 0, len(data.ScoringGroups))
 	if len(data.ScoringGroups) < 1 {
 		return nil, ErrNoDistributionData
 	}
 	for _, group := range data.ScoringGroups {
-		if group.Distribution.BoardData.HandN.Spades == "" &&
-			group.Distribution.BoardData.HandN.Hearts == "" &&
-			group.Distribution.BoardData.HandN.Diamonds == "" &&
-			group.Distribution.BoardData.HandN.Clubs == "" {
+		if !group.Distribution.BoardData.HasHandRecord() {
 			continue
 		}
 		tmpBoard := pbn.Board{
@@ -226,13 +413,17 @@ func (e *Extractor) ExtractOneFromUrl(baseUrl string, boardNumber int) ([]pbn.Bo
 			}{},
 			MinimaxScore: pbn.Contract{},
 		}
+		// without double dummy tricks the PBN leaves Ability and OptimumResultTable out instead of a table of zeros
+		if !group.Distribution.BoardData.HasDoubleDummy() {
+			tmpBoard.Ability = nil
+		}
 		if group.Distribution.BoardData.MiniMax != "" {
 			rawMinimaxData := group.Distribution.BoardData.MiniMax
 			rawMinimaxData = strings.Replace(rawMinimaxData, "nt", "n", 1)
 			rawMinimaxData = strings.Replace(rawMinimaxData, "NT", "n", 1)
 			rawMinimaxData = strings.Replace(rawMinimaxData, " ", "", -1)
 			rawMinimax := strings.Split(rawMinimaxData, "")
-			tmpBoard.MinimaxScore.Level, err = strconv.Atoi(rawMinimax[0])
+			tmpBoard.MinimaxScore.Level, _ = strconv.Atoi(rawMinimax[0])
 			tmpBoard.MinimaxScore.Suit = pbn.SuitFromSting(rawMinimax[1])
 			directionIndex := 2
 			if rawMinimax[2] == "X" || rawMinimax[2] == "x" {
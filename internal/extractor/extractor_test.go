@@ -0,0 +1,126 @@
+package extractor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExtractProtocolIfModified(t *testing.T) {
+	const etag = `"v1"`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		_, _ = w.Write([]byte(`{"ScoringGroups":[]}`))
+	}))
+	defer server.Close()
+
+	e := NewExtractor("test", time.Second)
+	_, validators, err := e.ExtractProtocolIfModified(server.URL, 1, Validators{})
+	if err != nil {
+		t.Fatalf("ExtractProtocolIfModified() error = %v", err)
+	}
+	if validators.ETag != etag {
+		t.Fatalf("ExtractProtocolIfModified() validators = %+v, want ETag %s", validators, etag)
+	}
+	_, _, err = e.ExtractProtocolIfModified(server.URL, 1, validators)
+	if !errors.Is(err, ErrNotModified) {
+		t.Errorf("ExtractProtocolIfModified() error = %v, want %v", err, ErrNotModified)
+	}
+}
+
+func TestExtractProtocolWithInfo_Retries(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"ScoringGroups":[]}`))
+	}))
+	defer server.Close()
+
+	e := NewExtractor("test", time.Second).WithRetries(2)
+	_, info, err := e.ExtractProtocolWithInfo(server.URL, 1)
+	if err != nil {
+		t.Fatalf("ExtractProtocolWithInfo() error = %v", err)
+	}
+	if info.Retries != 1 || info.StatusCode != http.StatusOK {
+		t.Errorf("ExtractProtocolWithInfo() info = %+v, want 1 retry and status 200", info)
+	}
+}
+
+func TestExtractor_WithContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	e := NewExtractor("test", time.Second).WithRetries(10).WithContext(ctx)
+	time.AfterFunc(50*time.Millisecond, cancel)
+	start := time.Now()
+	_, err := e.ExtractProtocolFromUrl(server.URL, 1)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("ExtractProtocolFromUrl() error = %v, want %v", err, context.Canceled)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("ExtractProtocolFromUrl() returned after %v, want right after cancellation", elapsed)
+	}
+}
+
+const testHandRecord = `"HandN": {"Spades": "AK5", "Hearts": "Q72", "Diamonds": "A984", "Clubs": "J63"},
+"HandE": {"Spades": "QJ10", "Hearts": "AK8", "Diamonds": "765", "Clubs": "K982"},
+"HandS": {"Spades": "9876", "Hearts": "J109", "Diamonds": "KQ", "Clubs": "AQ104"},
+"HandW": {"Spades": "432", "Hearts": "6543", "Diamonds": "J1032", "Clubs": "75"}`
+
+const testTricks = `"TricksFromN": {"Nt": 9, "Spades": 8, "Hearts": 7, "Diamonds": 8, "Clubs": 9},
+"TricksFromE": {"Nt": 4, "Spades": 5, "Hearts": 6, "Diamonds": 5, "Clubs": 4},
+"TricksFromS": {"Nt": 9, "Spades": 8, "Hearts": 7, "Diamonds": 8, "Clubs": 9},
+"TricksFromW": {"Nt": 4, "Spades": 5, "Hearts": 6, "Diamonds": 5, "Clubs": 4}`
+
+func TestBoardsFromProtocol_DoubleDummy(t *testing.T) {
+	tests := []struct {
+		name        string
+		handRecord  string
+		doubleDummy bool
+	}{
+		{name: "with tricks", handRecord: testHandRecord + ", " + testTricks, doubleDummy: true},
+		{name: "without tricks", handRecord: testHandRecord, doubleDummy: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var protocol RawProtocol
+			raw := `{"ScoringGroups": [{"Distribution": {"Number": 1, "_handRecord": {` + tt.handRecord + `}}}]}`
+			if err := json.Unmarshal([]byte(raw), &protocol); err != nil {
+				t.Fatal(err)
+			}
+			boards, err := BoardsFromProtocol(protocol)
+			if err != nil || len(boards) != 1 {
+				t.Fatalf("BoardsFromProtocol() = %v, %v, want one board", boards, err)
+			}
+			if got := boards[0].Ability != nil; got != tt.doubleDummy {
+				t.Fatalf("BoardsFromProtocol() has ability %v, want %v", got, tt.doubleDummy)
+			}
+			var b bytes.Buffer
+			if err = boards[0].Serialize(&b, true); err != nil {
+				t.Fatal(err)
+			}
+			for _, tag := range []string{"[Ability ", "[OptimumResultTable "} {
+				if got := strings.Contains(b.String(), tag); got != tt.doubleDummy {
+					t.Errorf("PBN has %s tag %v, want %v:\n%s", tag, got, tt.doubleDummy, b.String())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package extractor
+
+import (
+	"errors"
+)
+
+type BoardAvailability struct {
+	Number        int    `json:"number"`
+	StatusCode    int    `json:"statusCode,omitempty"`
+	Error         string `json:"error,omitempty"`
+	ScoringGroups int    `json:"scoringGroups"`
+	HandRecords   int    `json:"handRecords"`
+	DoubleDummy   int    `json:"doubleDummy"`
+	MiniMax       int    `json:"miniMax"`
+}
+
+func (a BoardAvailability) Available() bool {
+	return a.Error == "" && a.HandRecords > 0
+}
+
+// ProbeBoard fetches p<N>.json and reports what it holds without building boards out of it. Fetch errors are
+// reported in the result, not returned.
+func (e *Extractor) ProbeBoard(baseUrl string, boardNumber int) BoardAvailability {
+	availability := BoardAvailability{Number: boardNumber}
+	protocol, err := e.ExtractProtocolFromUrl(baseUrl, boardNumber)
+	if err != nil {
+		var statusErr StatusCodeError
+		if errors.As(err, &statusErr) {
+			availability.StatusCode = statusErr.StatusCode
+		}
+		availability.Error = err.Error()
+		return availability
+	}
+	availability.StatusCode = 200
+	availability.ScoringGroups = len(protocol.ScoringGroups)
+	for _, group := range protocol.ScoringGroups {
+		data := group.Distribution.BoardData
+		if data.HasHandRecord() {
+			availability.HandRecords++
+		}
+		if data.HasDoubleDummy() {
+			availability.DoubleDummy++
+		}
+		if data.MiniMax != "" {
+			availability.MiniMax++
+		}
+	}
+	return availability
+}
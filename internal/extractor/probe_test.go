@@ -0,0 +1,63 @@
+package extractor
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestExtractor_ProbeBoard(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/p1.json":
+			// one group is played with double dummy and minimax, one without, one before its hand record is published
+			_, _ = w.Write([]byte(`{"ScoringGroups": [
+				{"Distribution": {"_handRecord": {` + testHandRecord + `, ` + testTricks + `, "MiniMax": "3nN400"}}},
+				{"Distribution": {"_handRecord": {` + testHandRecord + `}}},
+				{"Distribution": {"_handRecord": {}}}
+			]}`))
+		case "/p2.json":
+			_, _ = w.Write([]byte(`{"ScoringGroups": [{"Distribution": {"_handRecord": {}}}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name      string
+		board     int
+		want      BoardAvailability
+		available bool
+	}{
+		{
+			name:      "published",
+			board:     1,
+			want:      BoardAvailability{Number: 1, StatusCode: http.StatusOK, ScoringGroups: 3, HandRecords: 2, DoubleDummy: 1, MiniMax: 1},
+			available: true,
+		},
+		{
+			name:  "no hand records",
+			board: 2,
+			want:  BoardAvailability{Number: 2, StatusCode: http.StatusOK, ScoringGroups: 1},
+		},
+		{
+			name:  "missing",
+			board: 3,
+			want:  BoardAvailability{Number: 3, StatusCode: http.StatusNotFound, Error: StatusCodeError{StatusCode: http.StatusNotFound}.Error()},
+		},
+	}
+	e := NewExtractor("test", time.Second).WithRetries(0)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := e.ProbeBoard(server.URL, tt.board)
+			if got != tt.want {
+				t.Errorf("ProbeBoard() = %+v, want %+v", got, tt.want)
+			}
+			if got.Available() != tt.available {
+				t.Errorf("Available() = %v, want %v", got.Available(), tt.available)
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package extractor
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func Test_rateLimiter_wait(t *testing.T) {
+	r := newRateLimiter(time.Hour)
+	if err := r.wait(context.Background()); err != nil {
+		t.Fatalf("wait() of the first slot error = %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := r.wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("wait() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if waited := time.Since(start); waited > time.Second {
+		t.Errorf("wait() returned after %v, want once the context is done", waited)
+	}
+}
@@ -0,0 +1,72 @@
+package memory
+
+import (
+	"context"
+	"strconv"
+	"sync"
+
+	"github.com/fe-dox/tc-pbn-extractor/internal/data"
+)
+
+// JobQueue is a FIFO queue for API and workers running in one process. Deliveries are never repeated, a vanished
+// worker means a vanished process and with it the whole queue.
+type JobQueue struct {
+	mu     sync.Mutex
+	jobs   []data.QueuedJob
+	nextId int
+	// notify has room for one signal, enough to wake a receiver after any number of enqueued jobs
+	notify chan struct{}
+}
+
+func NewJobQueue() *JobQueue {
+	return &JobQueue{notify: make(chan struct{}, 1)}
+}
+
+func (q *JobQueue) Enqueue(_ context.Context, job data.QueuedJob) error {
+	q.mu.Lock()
+	q.jobs = append(q.jobs, job)
+	q.mu.Unlock()
+	q.signal()
+	return nil
+}
+
+func (q *JobQueue) Receive(ctx context.Context) (data.Delivery, error) {
+	for {
+		q.mu.Lock()
+		if len(q.jobs) > 0 {
+			job := q.jobs[0]
+			q.jobs = q.jobs[1:]
+			q.nextId++
+			id := strconv.Itoa(q.nextId)
+			more := len(q.jobs) > 0
+			q.mu.Unlock()
+			if more {
+				q.signal()
+			}
+			return data.Delivery{Id: id, Job: job}, nil
+		}
+		q.mu.Unlock()
+		select {
+		case <-q.notify:
+		case <-ctx.Done():
+			return data.Delivery{}, ctx.Err()
+		}
+	}
+}
+
+func (q *JobQueue) Ack(context.Context, data.Delivery) error {
+	return nil
+}
+
+func (q *JobQueue) Retry(ctx context.Context, delivery data.Delivery) error {
+	job := delivery.Job
+	job.Attempt++
+	return q.Enqueue(ctx, job)
+}
+
+func (q *JobQueue) signal() {
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,41 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fe-dox/tc-pbn-extractor/internal/data"
+)
+
+func TestJobQueue(t *testing.T) {
+	q := NewJobQueue()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	received := make(chan data.Delivery)
+	go func() {
+		delivery, _ := q.Receive(ctx)
+		received <- delivery
+	}()
+	_ = q.Enqueue(ctx, data.QueuedJob{Hash: "first"})
+	_ = q.Enqueue(ctx, data.QueuedJob{Hash: "second"})
+	if delivery := <-received; delivery.Job.Hash != "first" {
+		t.Fatalf("Receive() = %+v, want the first job", delivery)
+	}
+	delivery, err := q.Receive(ctx)
+	if err != nil || delivery.Job.Hash != "second" {
+		t.Fatalf("Receive() = %+v, %v, want the second job", delivery, err)
+	}
+	_ = q.Retry(ctx, delivery)
+	retried, err := q.Receive(ctx)
+	if err != nil || retried.Job.Hash != "second" || retried.Job.Attempt != 1 || retried.Id == delivery.Id {
+		t.Fatalf("Receive() after retry = %+v, %v, want attempt 1 of the second job", retried, err)
+	}
+
+	short, cancelShort := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancelShort()
+	if delivery, err = q.Receive(short); err == nil {
+		t.Errorf("Receive() of an empty queue = %+v, want an error once the context is done", delivery)
+	}
+}
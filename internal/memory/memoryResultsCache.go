@@ -0,0 +1,350 @@
+package memory
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/fe-dox/tc-pbn-extractor/internal/data"
+)
+
+const (
+	progressSuffix = ":progress"
+	claimSuffix    = ":claim"
+	queuedSuffix   = ":queued"
+	// queueOwner owns queued jobs, so they are never evicted like claims
+	queueOwner = "queue"
+)
+
+// EvictionInterval is how often expired entries are removed in the background, reads never see them anyway.
+const EvictionInterval = time.Minute
+
+var ErrResultTooLarge = errors.New("result is larger than the cache memory limit")
+
+type entry struct {
+	owner string
+	value []byte
+	// cancelled is set on claims and queued jobs asked to stop and on results of jobs that stopped
+	cancelled bool
+	expires   time.Time
+}
+
+func (e *entry) size(key string) int64 {
+	return int64(len(key) + len(e.owner) + len(e.value))
+}
+
+// ResultsCache keeps jobs in process memory with the same TTLs as the Redis cache. Values are stored JSON
+// encoded like in Redis, so callers never share a result and the memory limit counts real bytes. When the limit
+// is reached finished results closest to expiring are evicted first, claims of jobs being processed never are.
+type ResultsCache struct {
+	mu            sync.Mutex
+	entries       map[string]*entry
+	pending       map[string]data.PendingJob
+	size          int64
+	maxBytes      int64
+	resultTTL     time.Duration
+	processingTTL time.Duration
+	now           func() time.Time
+	stop          chan struct{}
+	stopOnce      sync.Once
+}
+
+// NewResultsCache starts a cache holding at most maxBytes of results, 0 means no limit. Zero TTLs fall back to
+// data.DefaultResultTTL and data.DefaultProcessingTTL.
+func NewResultsCache(resultTTL time.Duration, processingTTL time.Duration, maxBytes int64) *ResultsCache {
+	if resultTTL == 0 {
+		resultTTL = data.DefaultResultTTL
+	}
+	if processingTTL == 0 {
+		processingTTL = data.DefaultProcessingTTL
+	}
+	r := &ResultsCache{
+		entries:       make(map[string]*entry),
+		pending:       make(map[string]data.PendingJob),
+		maxBytes:      maxBytes,
+		resultTTL:     resultTTL,
+		processingTTL: processingTTL,
+		now:           time.Now,
+		stop:          make(chan struct{}),
+	}
+	go r.evictPeriodically(EvictionInterval)
+	return r
+}
+
+// Close stops background eviction.
+func (r *ResultsCache) Close() {
+	r.stopOnce.Do(func() {
+		close(r.stop)
+	})
+}
+
+func (r *ResultsCache) Get(key string) (data.JobStatus, data.Result, error) {
+	r.mu.Lock()
+	claim, queued, e := r.get(key+claimSuffix), r.get(key+queuedSuffix), r.get(key)
+	r.mu.Unlock()
+	if claim != nil {
+		return data.JobProcessing, data.Result{}, nil
+	}
+	if queued != nil {
+		return data.JobQueued, data.Result{}, nil
+	}
+	if e == nil {
+		return data.JobNotFound, data.Result{}, nil
+	}
+	var result data.Result
+	err := json.Unmarshal(e.value, &result)
+	if err != nil {
+		return 0, data.Result{}, err
+	}
+	if e.cancelled {
+		return data.JobCancelled, result, nil
+	}
+	return data.JobDone, result, nil
+}
+
+func (r *ResultsCache) GetStatus(key string) (data.JobStatus, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.get(key+claimSuffix) != nil {
+		return data.JobProcessing, nil
+	}
+	if r.get(key+queuedSuffix) != nil {
+		return data.JobQueued, nil
+	}
+	e := r.get(key)
+	if e == nil {
+		return data.JobNotFound, nil
+	}
+	if e.cancelled {
+		return data.JobCancelled, nil
+	}
+	return data.JobDone, nil
+}
+
+func (r *ResultsCache) SaveResult(key string, value data.Result) error {
+	return r.saveResult(key, value, false)
+}
+
+func (r *ResultsCache) SaveCancelled(key string, value data.Result) error {
+	return r.saveResult(key, value, true)
+}
+
+func (r *ResultsCache) saveResult(key string, value data.Result, cancelled bool) error {
+	parsedData, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.pending, key)
+	return r.set(key, &entry{value: parsedData, cancelled: cancelled, expires: r.now().Add(r.resultTTL)})
+}
+
+func (r *ResultsCache) Claim(key string, owner string, options data.Options) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.get(key+claimSuffix) != nil {
+		return false, nil
+	}
+	err := r.set(key+claimSuffix, &entry{owner: owner, expires: r.now().Add(r.processingTTL)})
+	if err != nil {
+		return false, err
+	}
+	r.pending[key] = data.PendingJob{Options: options, Attempts: r.pending[key].Attempts + 1}
+	return true, nil
+}
+
+func (r *ResultsCache) Queue(key string, options data.Options) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.get(key+claimSuffix) != nil || r.get(key+queuedSuffix) != nil {
+		return false, nil
+	}
+	err := r.set(key+queuedSuffix, &entry{owner: queueOwner, expires: r.now().Add(data.QueuedTTL)})
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *ResultsCache) Take(key string, owner string, options data.Options) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	queued := r.get(key + queuedSuffix)
+	if queued == nil || r.get(key+claimSuffix) != nil {
+		return false, nil
+	}
+	r.remove(key + queuedSuffix)
+	err := r.set(key+claimSuffix, &entry{owner: owner, cancelled: queued.cancelled, expires: r.now().Add(r.processingTTL)})
+	if err != nil {
+		return false, err
+	}
+	r.pending[key] = data.PendingJob{Options: options, Attempts: r.pending[key].Attempts + 1}
+	return true, nil
+}
+
+func (r *ResultsCache) Cancel(key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, suffix := range []string{claimSuffix, queuedSuffix} {
+		if e := r.get(key + suffix); e != nil {
+			e.cancelled = true
+			return nil
+		}
+	}
+	return data.ErrNotClaimed
+}
+
+func (r *ResultsCache) CancelRequested(key string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, suffix := range []string{claimSuffix, queuedSuffix} {
+		if e := r.get(key + suffix); e != nil {
+			return e.cancelled, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *ResultsCache) Abandoned() (map[string]data.PendingJob, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	abandoned := make(map[string]data.PendingJob)
+	for key, pending := range r.pending {
+		if r.get(key+claimSuffix) == nil && r.get(key+queuedSuffix) == nil {
+			abandoned[key] = pending
+		}
+	}
+	return abandoned, nil
+}
+
+func (r *ResultsCache) Release(key string, owner string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	claim := r.get(key + claimSuffix)
+	if claim == nil || claim.owner != owner {
+		return data.ErrClaimLost
+	}
+	r.remove(key + claimSuffix)
+	return nil
+}
+
+func (r *ResultsCache) Refresh(key string, owner string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	claim := r.get(key + claimSuffix)
+	if claim == nil || claim.owner != owner {
+		return data.ErrClaimLost
+	}
+	claim.expires = r.now().Add(r.processingTTL)
+	return nil
+}
+
+func (r *ResultsCache) GetProgress(key string) (data.Progress, error) {
+	r.mu.Lock()
+	e := r.get(key + progressSuffix)
+	r.mu.Unlock()
+	if e == nil {
+		return data.Progress{}, nil
+	}
+	var progress data.Progress
+	err := json.Unmarshal(e.value, &progress)
+	if err != nil {
+		return data.Progress{}, err
+	}
+	return progress, nil
+}
+
+// SaveProgress keeps progress as long as a result would be kept, so finished jobs still report their totals.
+func (r *ResultsCache) SaveProgress(key string, progress data.Progress) error {
+	parsedData, err := json.Marshal(progress)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.set(key+progressSuffix, &entry{value: parsedData, expires: r.now().Add(r.resultTTL)})
+}
+
+// get returns an entry that has not expired yet, r.mu must be held.
+func (r *ResultsCache) get(key string) *entry {
+	e, ok := r.entries[key]
+	if !ok {
+		return nil
+	}
+	if !r.now().Before(e.expires) {
+		r.remove(key)
+		return nil
+	}
+	return e
+}
+
+// set replaces an entry, evicting others if the memory limit would be exceeded. r.mu must be held.
+func (r *ResultsCache) set(key string, e *entry) error {
+	r.remove(key)
+	if r.maxBytes > 0 {
+		if e.size(key) > r.maxBytes {
+			return ErrResultTooLarge
+		}
+		r.evict(r.maxBytes - e.size(key))
+	}
+	r.entries[key] = e
+	r.size += e.size(key)
+	return nil
+}
+
+func (r *ResultsCache) remove(key string) {
+	if e, ok := r.entries[key]; ok {
+		r.size -= e.size(key)
+		delete(r.entries, key)
+	}
+}
+
+// evict drops expired entries and then finished ones closest to expiring until at most limit bytes are used.
+func (r *ResultsCache) evict(limit int64) {
+	r.removeExpired()
+	if r.size <= limit {
+		return
+	}
+	keys := make([]string, 0, len(r.entries))
+	for key, e := range r.entries {
+		if e.owner == "" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return r.entries[keys[i]].expires.Before(r.entries[keys[j]].expires)
+	})
+	for _, key := range keys {
+		if r.size <= limit {
+			return
+		}
+		r.remove(key)
+	}
+}
+
+func (r *ResultsCache) removeExpired() {
+	now := r.now()
+	for key, e := range r.entries {
+		if !now.Before(e.expires) {
+			r.remove(key)
+		}
+	}
+}
+
+func (r *ResultsCache) evictPeriodically(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			r.mu.Lock()
+			r.removeExpired()
+			r.mu.Unlock()
+		case <-r.stop:
+			return
+		}
+	}
+}
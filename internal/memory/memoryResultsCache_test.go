@@ -0,0 +1,90 @@
+package memory
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fe-dox/tc-pbn-extractor/internal/data"
+	"github.com/fe-dox/tc-pbn-extractor/internal/data/datatest"
+)
+
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+func newTestCache(t *testing.T, maxBytes int64) (*ResultsCache, *clock) {
+	c := &clock{now: time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)}
+	r := NewResultsCache(0, 0, maxBytes)
+	r.now = c.Now
+	t.Cleanup(r.Close)
+	return r, c
+}
+
+func TestResultsCache_TTL(t *testing.T) {
+	r, c := newTestCache(t, 0)
+	_, _ = r.Claim("job", "owner", data.Options{})
+	if status, _ := r.GetStatus("job"); status != data.JobProcessing {
+		t.Fatalf("GetStatus() = %v, want processing", status)
+	}
+	c.now = c.now.Add(data.DefaultProcessingTTL)
+	if status, _ := r.GetStatus("job"); status != data.JobNotFound {
+		t.Fatalf("GetStatus() after processing TTL = %v, want not found", status)
+	}
+
+	_ = r.SaveResult("job", data.Result{Success: true, EventName: "Pairs"})
+	c.now = c.now.Add(data.DefaultResultTTL - time.Second)
+	status, result, err := r.Get("job")
+	if err != nil || status != data.JobDone || result.EventName != "Pairs" {
+		t.Fatalf("Get() = %v, %+v, %v, want done result", status, result, err)
+	}
+	c.now = c.now.Add(time.Second)
+	if status, _ := r.GetStatus("job"); status != data.JobNotFound {
+		t.Fatalf("GetStatus() after result TTL = %v, want not found", status)
+	}
+	if r.size != 0 {
+		t.Errorf("size after expiry = %d, want 0", r.size)
+	}
+}
+
+func TestResultsCache_MemoryLimit(t *testing.T) {
+	r, c := newTestCache(t, 600)
+	result := data.Result{BoardSets: []string{strings.Repeat("x", 150)}}
+	_, _ = r.Claim("running", "owner", data.Options{})
+	_ = r.SaveResult("first", result)
+	c.now = c.now.Add(time.Second)
+	_ = r.SaveResult("second", result)
+	c.now = c.now.Add(time.Second)
+	_ = r.SaveResult("third", result)
+
+	if status, _ := r.GetStatus("first"); status != data.JobNotFound {
+		t.Errorf("GetStatus(first) = %v, want evicted", status)
+	}
+	for _, key := range []string{"running", "second", "third"} {
+		if status, _ := r.GetStatus(key); status == data.JobNotFound {
+			t.Errorf("GetStatus(%s) = not found, want kept", key)
+		}
+	}
+	if r.size > r.maxBytes {
+		t.Errorf("size = %d, over limit %d", r.size, r.maxBytes)
+	}
+
+	err := r.SaveResult("huge", data.Result{BoardSets: []string{strings.Repeat("x", 1000)}})
+	if !errors.Is(err, ErrResultTooLarge) {
+		t.Errorf("SaveResult() error = %v, want %v", err, ErrResultTooLarge)
+	}
+}
+
+func TestResultsCache_Conformance(t *testing.T) {
+	datatest.TestResultsCache(t, func(t *testing.T) (data.ResultsCache, func(time.Duration)) {
+		r, c := newTestCache(t, 0)
+		return r, func(d time.Duration) {
+			c.now = c.now.Add(d)
+		}
+	})
+}
@@ -0,0 +1,129 @@
+package pbnfile
+
+import (
+	"bufio"
+	"bytes"
+	"github.com/fe-dox/go-pbn"
+	"github.com/fe-dox/tc-pbn-extractor/internal/deal"
+	"io"
+	"strings"
+)
+
+// supportedTags are the tags go-pbn's parser understands. It prints every other tag to stdout, so they are
+// dropped before parsing.
+var supportedTags = map[string]bool{
+	"Event":              true,
+	"Generator":          true,
+	"Board":              true,
+	"Dealer":             true,
+	"Vulnerable":         true,
+	"Deal":               true,
+	"Ability":            true,
+	"OptimumResultTable": true,
+	"OptimumScore":       true,
+	"Minimax":            true,
+}
+
+// Read parses a PBN file. Unlike pbn.ParsePBN it tolerates unsupported tags, multi-line comments, several blank
+// lines between boards, a missing trailing blank line and deals which do not start with North.
+func Read(r io.Reader) ([]pbn.Board, error) {
+	boards, _, err := ReadTag(r, "")
+	return boards, err
+}
+
+// ReadTag is Read that also returns the value of tag, which go-pbn doesn't understand, for every board. Boards
+// without the tag get an empty value.
+func ReadTag(r io.Reader, tag string) ([]pbn.Board, []string, error) {
+	var cleaned bytes.Buffer
+	// values has one value per block of lines, go-pbn turns every block into a board
+	var values []string
+	var value string
+	scanner := bufio.NewScanner(r)
+	previousBlank := true
+	inComment := false
+	endBlock := func() {
+		cleaned.WriteString("\n")
+		values = append(values, value)
+		value = ""
+	}
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r\t ")
+		if inComment {
+			inComment = !strings.Contains(line, "}")
+			continue
+		}
+		if strings.HasPrefix(line, "{") {
+			inComment = !strings.Contains(line, "}")
+			continue
+		}
+		if line == "" {
+			if !previousBlank {
+				endBlock()
+			}
+			previousBlank = true
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			name := strings.TrimPrefix(strings.SplitN(line, " ", 2)[0], "[")
+			if tag != "" && name == tag {
+				value = tagValue(line)
+			}
+			if !supportedTags[name] {
+				continue
+			}
+			if name == "Deal" {
+				line = normalizeDeal(line)
+			}
+		}
+		cleaned.WriteString(line)
+		cleaned.WriteString("\n")
+		previousBlank = false
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+	if !previousBlank {
+		endBlock()
+	}
+	boardSet := pbn.ParsePBN(&cleaned)
+	if len(values) != len(boardSet.Boards) {
+		values = make([]string, len(boardSet.Boards))
+	}
+	boards := make([]pbn.Board, 0, len(boardSet.Boards))
+	kept := values[:0]
+	for i, board := range boardSet.Boards {
+		if board.Number == 0 && deal.IsEmpty(board) {
+			continue
+		}
+		boards = append(boards, board)
+		kept = append(kept, values[i])
+	}
+	return boards, kept, nil
+}
+
+// tagValue returns the quoted value of a [Name "value"] line.
+func tagValue(line string) string {
+	parts := strings.SplitN(line, " ", 2)
+	if len(parts) < 2 {
+		return ""
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(strings.TrimSuffix(parts[1], "]"), "\""), "\"")
+}
+
+// normalizeDeal rotates a [Deal "E:..."] tag so that the first hand is North's, the only form go-pbn parses.
+func normalizeDeal(line string) string {
+	value := strings.TrimSuffix(strings.TrimPrefix(line, "[Deal \""), "\"]")
+	if len(value) < 2 || value[1] != ':' {
+		return line
+	}
+	first := pbn.DirectionFromRune(rune(value[0]))
+	hands := strings.Fields(value[2:])
+	if first == pbn.North || len(hands) != 4 {
+		return line
+	}
+	rotated := make([]string, 4)
+	for i, hand := range hands {
+		rotated[(int(first)+i)%4] = hand
+	}
+	return "[Deal \"N:" + strings.Join(rotated, " ") + "\"]"
+}
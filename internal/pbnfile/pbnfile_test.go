@@ -0,0 +1,77 @@
+package pbnfile
+
+import (
+	"github.com/fe-dox/go-pbn"
+	"strings"
+	"testing"
+)
+
+const testFile = `% PBN 2.1
+[Event "Club Pairs"]
+[Site "Warsaw"]
+[Board "1"]
+[Dealer "N"]
+[Vulnerable "None"]
+[Deal "W:432.6543.JT32.75 AK5.Q72.A984.J63 QJT.AK8.765.K982 9876.JT9.KQ.AQT4"]
+{ a comment
+spanning lines }
+
+
+[Board "2"]
+[Dealer "E"]
+[Vulnerable "NS"]
+[Deal "N:AK5.Q72.A984.J63 QJT.AK8.765.K982 9876.JT9.KQ.AQT4 432.6543.JT32.75"]`
+
+func TestRead(t *testing.T) {
+	boards, err := Read(strings.NewReader(testFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(boards) != 2 {
+		t.Fatalf("Read() got %d boards, want 2", len(boards))
+	}
+	for _, board := range boards {
+		north := board.Hands[pbn.North]
+		if got := north.String(); got != "AK5.Q72.A984.J63" {
+			t.Errorf("board %d North = %s, want AK5.Q72.A984.J63", board.Number, got)
+		}
+		west := board.Hands[pbn.West]
+		if got := west.String(); got != "432.6543.JT32.75" {
+			t.Errorf("board %d West = %s, want 432.6543.JT32.75", board.Number, got)
+		}
+	}
+	if boards[1].Number != 2 || boards[1].Vulnerable != pbn.NorthSouth {
+		t.Errorf("unexpected second board %+v", boards[1])
+	}
+}
+
+func TestReadTag(t *testing.T) {
+	file := "% PBN 2.1\n\n[TcBoard \"7\"]\n" + strings.SplitN(testFile, "\n", 2)[1]
+	boards, values, err := ReadTag(strings.NewReader(file), "TcBoard")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(boards) != 2 || len(values) != 2 || values[0] != "7" || values[1] != "" {
+		t.Errorf("ReadTag() = %d boards, values %q, want 7 for the first of 2 boards", len(boards), values)
+	}
+}
+
+func Test_normalizeDeal(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want string
+	}{
+		{name: "north first", line: `[Deal "N:A.B.C.D E.F.G.H I.J.K.L M.N.O.P"]`, want: `[Deal "N:A.B.C.D E.F.G.H I.J.K.L M.N.O.P"]`},
+		{name: "east first", line: `[Deal "E:A.B.C.D E.F.G.H I.J.K.L M.N.O.P"]`, want: `[Deal "N:M.N.O.P A.B.C.D E.F.G.H I.J.K.L"]`},
+		{name: "south first", line: `[Deal "S:A.B.C.D E.F.G.H I.J.K.L M.N.O.P"]`, want: `[Deal "N:I.J.K.L M.N.O.P A.B.C.D E.F.G.H"]`},
+		{name: "incomplete", line: `[Deal "E:A.B.C.D"]`, want: `[Deal "E:A.B.C.D"]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeDeal(tt.line); got != tt.want {
+				t.Errorf("normalizeDeal() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
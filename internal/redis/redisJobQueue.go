@@ -0,0 +1,136 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/fe-dox/tc-pbn-extractor/internal/data"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	queueStream = "jobs:queue"
+	queueGroup  = "workers"
+	jobField    = "job"
+)
+
+// DefaultRedeliverAfter is how long a delivery may stay unacknowledged before another worker receives it again.
+const DefaultRedeliverAfter = 10 * time.Minute
+
+// receiveBlock bounds a single blocking read, so Receive notices a cancelled context in time.
+const receiveBlock = 2 * time.Second
+
+// JobQueue is a Redis stream read by a consumer group, each worker is a consumer of its own name. Acknowledged
+// entries are deleted, deliveries left unacknowledged by vanished workers are claimed by live ones.
+type JobQueue struct {
+	rdb            *redis.Client
+	consumer       string
+	redeliverAfter time.Duration
+}
+
+// NewJobQueue connects to Redis and creates the consumer group if it doesn't exist yet, a zero redeliverAfter
+// falls back to DefaultRedeliverAfter.
+func NewJobQueue(connectionUrl string, consumer string, redeliverAfter time.Duration) (*JobQueue, error) {
+	if redeliverAfter == 0 {
+		redeliverAfter = DefaultRedeliverAfter
+	}
+	options, err := redis.ParseURL(connectionUrl)
+	if err != nil {
+		return nil, err
+	}
+	rdb := redis.NewClient(options)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*1)
+	defer cancel()
+	err = rdb.XGroupCreateMkStream(ctx, queueStream, queueGroup, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return nil, err
+	}
+	return &JobQueue{rdb: rdb, consumer: consumer, redeliverAfter: redeliverAfter}, nil
+}
+
+func (q *JobQueue) Enqueue(ctx context.Context, job data.QueuedJob) error {
+	parsedData, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	return q.rdb.XAdd(ctx, &redis.XAddArgs{Stream: queueStream, Values: map[string]interface{}{jobField: string(parsedData)}}).Err()
+}
+
+// Receive prefers deliveries abandoned by other workers over new entries.
+func (q *JobQueue) Receive(ctx context.Context) (data.Delivery, error) {
+	for {
+		messages, _, err := q.rdb.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+			Stream:   queueStream,
+			Group:    queueGroup,
+			Consumer: q.consumer,
+			MinIdle:  q.redeliverAfter,
+			Start:    "0-0",
+			Count:    1,
+		}).Result()
+		if err != nil {
+			return data.Delivery{}, err
+		}
+		if len(messages) == 0 {
+			var streams []redis.XStream
+			streams, err = q.rdb.XReadGroup(ctx, &redis.XReadGroupArgs{
+				Group:    queueGroup,
+				Consumer: q.consumer,
+				Streams:  []string{queueStream, ">"},
+				Count:    1,
+				Block:    receiveBlock,
+			}).Result()
+			if err != nil && !errors.Is(err, redis.Nil) {
+				return data.Delivery{}, err
+			}
+			for _, stream := range streams {
+				messages = append(messages, stream.Messages...)
+			}
+		}
+		if len(messages) > 0 {
+			return q.delivery(ctx, messages[0])
+		}
+		if ctx.Err() != nil {
+			return data.Delivery{}, ctx.Err()
+		}
+	}
+}
+
+// delivery decodes a stream entry, entries that are not jobs are acknowledged and dropped.
+func (q *JobQueue) delivery(ctx context.Context, message redis.XMessage) (data.Delivery, error) {
+	delivery := data.Delivery{Id: message.ID}
+	strJob, _ := message.Values[jobField].(string)
+	err := json.Unmarshal([]byte(strJob), &delivery.Job)
+	if err != nil {
+		_ = q.Ack(ctx, delivery)
+		return data.Delivery{}, err
+	}
+	return delivery, nil
+}
+
+func (q *JobQueue) Ack(ctx context.Context, delivery data.Delivery) error {
+	_, err := q.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.XAck(ctx, queueStream, queueGroup, delivery.Id)
+		pipe.XDel(ctx, queueStream, delivery.Id)
+		return nil
+	})
+	return err
+}
+
+func (q *JobQueue) Retry(ctx context.Context, delivery data.Delivery) error {
+	job := delivery.Job
+	job.Attempt++
+	parsedData, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	_, err = q.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.XAdd(ctx, &redis.XAddArgs{Stream: queueStream, Values: map[string]interface{}{jobField: string(parsedData)}})
+		pipe.XAck(ctx, queueStream, queueGroup, delivery.Id)
+		pipe.XDel(ctx, queueStream, delivery.Id)
+		return nil
+	})
+	return err
+}
@@ -0,0 +1,54 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/fe-dox/tc-pbn-extractor/internal/data"
+)
+
+func TestJobQueue(t *testing.T) {
+	mr := miniredis.RunT(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	first, err := NewJobQueue("redis://"+mr.Addr(), "first", 50*time.Millisecond)
+	if err != nil {
+		t.Fatalf("NewJobQueue() error = %v", err)
+	}
+	second, err := NewJobQueue("redis://"+mr.Addr(), "second", 50*time.Millisecond)
+	if err != nil {
+		t.Fatalf("NewJobQueue() of the same group error = %v", err)
+	}
+
+	job := data.QueuedJob{Hash: "job", Options: data.Options{BaseUrl: "https://example.com/t1/"}}
+	_ = first.Enqueue(ctx, job)
+	delivery, err := first.Receive(ctx)
+	if err != nil || delivery.Job != job {
+		t.Fatalf("Receive() = %+v, %v, want %+v", delivery, err, job)
+	}
+	if err = first.Retry(ctx, delivery); err != nil {
+		t.Fatalf("Retry() error = %v", err)
+	}
+	retried, err := second.Receive(ctx)
+	if err != nil || retried.Job.Attempt != 1 || retried.Id == delivery.Id {
+		t.Fatalf("Receive() after retry = %+v, %v, want attempt 1 in a new delivery", retried, err)
+	}
+
+	// the second worker vanishes without acknowledging, so the delivery goes to the first one
+	time.Sleep(100 * time.Millisecond)
+	redelivered, err := first.Receive(ctx)
+	if err != nil || redelivered.Id != retried.Id {
+		t.Fatalf("Receive() of an abandoned delivery = %+v, %v, want %s", redelivered, err, retried.Id)
+	}
+	if err = first.Ack(ctx, redelivered); err != nil {
+		t.Fatalf("Ack() error = %v", err)
+	}
+
+	short, cancelShort := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancelShort()
+	if delivery, err = second.Receive(short); err == nil {
+		t.Errorf("Receive() of an empty queue = %+v, want an error once the context is done", delivery)
+	}
+}
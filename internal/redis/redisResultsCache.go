@@ -9,22 +9,43 @@ import (
 	"time"
 )
 
+// PROCESSING is what older versions stored in place of a result while a job was processing.
 const PROCESSING = "processing"
 
+const (
+	progressSuffix = ":progress"
+	claimSuffix    = ":claim"
+	queuedSuffix   = ":queued"
+	// cancelSuffix marks claimed or queued jobs asked to stop, cancelledSuffix results of jobs that stopped.
+	cancelSuffix    = ":cancel"
+	cancelledSuffix = ":cancelled"
+	// pendingKey is a hash of pending jobs keyed by job hash.
+	pendingKey = "jobs:pending"
+)
+
 type ResultsCache struct {
-	rdb *redis.Client
+	rdb           *redis.Client
+	resultTTL     time.Duration
+	processingTTL time.Duration
 }
 
 func (r ResultsCache) Get(key string) (data.JobStatus, data.Result, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*1)
 	defer cancel()
-	strResult, err := r.rdb.Get(ctx, key).Result()
+	values, err := r.rdb.MGet(ctx, key+claimSuffix, key, key+cancelledSuffix, key+queuedSuffix).Result()
 	if err != nil {
-		if errors.Is(err, redis.Nil) {
-			return data.JobNotFound, data.Result{}, nil
-		}
 		return data.JobNotFound, data.Result{}, err
 	}
+	if values[0] != nil {
+		return data.JobProcessing, data.Result{}, nil
+	}
+	if values[3] != nil {
+		return data.JobQueued, data.Result{}, nil
+	}
+	strResult, ok := values[1].(string)
+	if !ok {
+		return data.JobNotFound, data.Result{}, nil
+	}
 	if strResult == PROCESSING {
 		return data.JobProcessing, data.Result{}, err
 	}
@@ -33,50 +54,290 @@ func (r ResultsCache) Get(key string) (data.JobStatus, data.Result, error) {
 	if err != nil {
 		return 0, data.Result{}, err
 	}
+	if values[2] != nil {
+		return data.JobCancelled, result, nil
+	}
 	return data.JobDone, result, nil
 }
 
 func (r ResultsCache) GetStatus(key string) (data.JobStatus, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*1)
 	defer cancel()
-	strResult, err := r.rdb.Get(ctx, key).Result()
+	values, err := r.rdb.MGet(ctx, key+claimSuffix, key, key+cancelledSuffix, key+queuedSuffix).Result()
 	if err != nil {
-		if errors.Is(err, redis.Nil) {
-			return data.JobNotFound, nil
-		}
 		return data.JobNotFound, err
 	}
-	if strResult == PROCESSING {
-		return data.JobProcessing, err
+	if values[0] != nil || values[1] == PROCESSING {
+		return data.JobProcessing, nil
+	}
+	if values[3] != nil {
+		return data.JobQueued, nil
+	}
+	if values[1] == nil {
+		return data.JobNotFound, nil
+	}
+	if values[2] != nil {
+		return data.JobCancelled, nil
 	}
 	return data.JobDone, nil
 }
 
 func (r ResultsCache) SaveResult(key string, value data.Result) error {
+	return r.saveResult(key, value, false)
+}
+
+func (r ResultsCache) SaveCancelled(key string, value data.Result) error {
+	return r.saveResult(key, value, true)
+}
+
+func (r ResultsCache) saveResult(key string, value data.Result, cancelled bool) error {
 	parsedData, err := json.Marshal(value)
 	if err != nil {
 		return err
 	}
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*1)
 	defer cancel()
-	_, err = r.rdb.Set(ctx, key, string(parsedData), time.Minute*15).Result()
+	_, err = r.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, key, string(parsedData), r.resultTTL)
+		if cancelled {
+			pipe.Set(ctx, key+cancelledSuffix, "1", r.resultTTL)
+		} else {
+			pipe.Del(ctx, key+cancelledSuffix)
+		}
+		pipe.HDel(ctx, pendingKey, key)
+		return nil
+	})
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func (r ResultsCache) SetStatusProcessing(key string) error {
+// Claim watches the claim key and sets it together with the pending job in one transaction, so of concurrent
+// claims of a job exactly one succeeds, and nobody sees the claim without its attempt counted.
+func (r ResultsCache) Claim(key string, owner string, options data.Options) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*1)
+	defer cancel()
+	claimed := false
+	err := r.rdb.Watch(ctx, func(tx *redis.Tx) error {
+		exists, err := tx.Exists(ctx, key+claimSuffix).Result()
+		if err != nil || exists > 0 {
+			return err
+		}
+		parsedData, err := nextAttempt(ctx, tx, key, options)
+		if err != nil {
+			return err
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, key+claimSuffix, owner, r.processingTTL)
+			pipe.Del(ctx, key+cancelSuffix)
+			pipe.HSet(ctx, pendingKey, key, parsedData)
+			return nil
+		})
+		claimed = err == nil
+		return err
+	}, key+claimSuffix)
+	if errors.Is(err, redis.TxFailedErr) {
+		return false, nil
+	}
+	return claimed, err
+}
+
+// nextAttempt returns the pending job of a job being claimed, with one more attempt.
+func nextAttempt(ctx context.Context, rdb redis.Cmdable, key string, options data.Options) (string, error) {
+	pending := data.PendingJob{Options: options}
+	strPending, err := rdb.HGet(ctx, pendingKey, key).Result()
+	if err == nil {
+		err = json.Unmarshal([]byte(strPending), &pending)
+	}
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return "", err
+	}
+	pending.Options = options
+	pending.Attempts++
+	parsedData, err := json.Marshal(pending)
+	return string(parsedData), err
+}
+
+// Queue and Take watch both the claim and the queued key, so of concurrent calls for a job exactly one goes
+// through.
+func (r ResultsCache) Queue(key string, options data.Options) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*1)
+	defer cancel()
+	queued := false
+	err := r.rdb.Watch(ctx, func(tx *redis.Tx) error {
+		exists, err := tx.Exists(ctx, key+claimSuffix, key+queuedSuffix).Result()
+		if err != nil || exists > 0 {
+			return err
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, key+queuedSuffix, "1", data.QueuedTTL)
+			pipe.Del(ctx, key+cancelSuffix)
+			return nil
+		})
+		queued = err == nil
+		return err
+	}, key+claimSuffix, key+queuedSuffix)
+	if errors.Is(err, redis.TxFailedErr) {
+		return false, nil
+	}
+	return queued, err
+}
+
+func (r ResultsCache) Take(key string, owner string, options data.Options) (bool, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*1)
 	defer cancel()
-	_, err := r.rdb.Set(ctx, key, PROCESSING, time.Minute*5).Result()
+	taken := false
+	err := r.rdb.Watch(ctx, func(tx *redis.Tx) error {
+		values, err := tx.MGet(ctx, key+claimSuffix, key+queuedSuffix).Result()
+		if err != nil || values[0] != nil || values[1] == nil {
+			return err
+		}
+		parsedData, err := nextAttempt(ctx, tx, key, options)
+		if err != nil {
+			return err
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Del(ctx, key+queuedSuffix)
+			pipe.Set(ctx, key+claimSuffix, owner, r.processingTTL)
+			pipe.HSet(ctx, pendingKey, key, parsedData)
+			return nil
+		})
+		taken = err == nil
+		return err
+	}, key+claimSuffix, key+queuedSuffix)
+	if errors.Is(err, redis.TxFailedErr) {
+		return false, nil
+	}
+	return taken, err
+}
+
+func (r ResultsCache) Abandoned() (map[string]data.PendingJob, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	all, err := r.rdb.HGetAll(ctx, pendingKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	abandoned := make(map[string]data.PendingJob)
+	for key, strPending := range all {
+		held, err := r.rdb.Exists(ctx, key+claimSuffix, key+queuedSuffix).Result()
+		if err != nil {
+			return nil, err
+		}
+		if held > 0 {
+			continue
+		}
+		var pending data.PendingJob
+		err = json.Unmarshal([]byte(strPending), &pending)
+		if err != nil {
+			return nil, err
+		}
+		abandoned[key] = pending
+	}
+	return abandoned, nil
+}
+
+func (r ResultsCache) Release(key string, owner string) error {
+	return r.ifOwner(key, owner, func(ctx context.Context, pipe redis.Pipeliner) {
+		pipe.Del(ctx, key+claimSuffix)
+	})
+}
+
+func (r ResultsCache) Refresh(key string, owner string) error {
+	return r.ifOwner(key, owner, func(ctx context.Context, pipe redis.Pipeliner) {
+		pipe.PExpire(ctx, key+claimSuffix, r.processingTTL)
+	})
+}
+
+// Cancel doesn't need a transaction, a request left behind by a job released meanwhile is deleted by the next
+// Claim or Queue. It lasts as long as a job may stay queued, so it is seen by the worker taking the job.
+func (r ResultsCache) Cancel(key string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*1)
+	defer cancel()
+	held, err := r.rdb.Exists(ctx, key+claimSuffix, key+queuedSuffix).Result()
+	if err != nil {
+		return err
+	}
+	if held == 0 {
+		return data.ErrNotClaimed
+	}
+	return r.rdb.Set(ctx, key+cancelSuffix, "1", data.QueuedTTL).Err()
+}
+
+func (r ResultsCache) CancelRequested(key string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*1)
+	defer cancel()
+	requested, err := r.rdb.Exists(ctx, key+cancelSuffix).Result()
+	return requested > 0, err
+}
+
+// ifOwner runs commands queued by fn in a transaction, which only goes through if the claim still belongs to
+// owner and nobody changed it meanwhile.
+func (r ResultsCache) ifOwner(key string, owner string, fn func(ctx context.Context, pipe redis.Pipeliner)) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*1)
+	defer cancel()
+	err := r.rdb.Watch(ctx, func(tx *redis.Tx) error {
+		current, err := tx.Get(ctx, key+claimSuffix).Result()
+		if errors.Is(err, redis.Nil) || (err == nil && current != owner) {
+			return data.ErrClaimLost
+		}
+		if err != nil {
+			return err
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			fn(ctx, pipe)
+			return nil
+		})
+		return err
+	}, key+claimSuffix)
+	if errors.Is(err, redis.TxFailedErr) {
+		return data.ErrClaimLost
+	}
+	return err
+}
+
+func (r ResultsCache) GetProgress(key string) (data.Progress, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*1)
+	defer cancel()
+	strProgress, err := r.rdb.Get(ctx, key+progressSuffix).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return data.Progress{}, nil
+		}
+		return data.Progress{}, err
+	}
+	var progress data.Progress
+	err = json.Unmarshal([]byte(strProgress), &progress)
+	if err != nil {
+		return data.Progress{}, err
+	}
+	return progress, nil
+}
+
+// SaveProgress keeps progress as long as a result would be kept, so finished jobs still report their totals.
+func (r ResultsCache) SaveProgress(key string, progress data.Progress) error {
+	parsedData, err := json.Marshal(progress)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*1)
+	defer cancel()
+	_, err = r.rdb.Set(ctx, key+progressSuffix, string(parsedData), r.resultTTL).Result()
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func NewResultsCache(connectionUrl string) (*ResultsCache, error) {
+// NewResultsCache connects to Redis, zero TTLs fall back to data.DefaultResultTTL and data.DefaultProcessingTTL.
+func NewResultsCache(connectionUrl string, resultTTL time.Duration, processingTTL time.Duration) (*ResultsCache, error) {
+	if resultTTL == 0 {
+		resultTTL = data.DefaultResultTTL
+	}
+	if processingTTL == 0 {
+		processingTTL = data.DefaultProcessingTTL
+	}
 	options, err := redis.ParseURL(connectionUrl)
 	if err != nil {
 		return nil, err
@@ -86,5 +347,5 @@ func NewResultsCache(connectionUrl string) (*ResultsCache, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &ResultsCache{rdb: rdb}, nil
+	return &ResultsCache{rdb: rdb, resultTTL: resultTTL, processingTTL: processingTTL}, nil
 }
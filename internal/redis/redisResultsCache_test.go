@@ -0,0 +1,21 @@
+package redis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/fe-dox/tc-pbn-extractor/internal/data"
+	"github.com/fe-dox/tc-pbn-extractor/internal/data/datatest"
+)
+
+func TestResultsCache_Conformance(t *testing.T) {
+	datatest.TestResultsCache(t, func(t *testing.T) (data.ResultsCache, func(time.Duration)) {
+		mr := miniredis.RunT(t)
+		r, err := NewResultsCache("redis://"+mr.Addr(), 0, 0)
+		if err != nil {
+			t.Fatalf("NewResultsCache() error = %v", err)
+		}
+		return r, mr.FastForward
+	})
+}
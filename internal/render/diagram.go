@@ -0,0 +1,155 @@
+package render
+
+import (
+	"fmt"
+	"github.com/fe-dox/go-pbn"
+	"github.com/fe-dox/tc-pbn-extractor/internal/export"
+	"image/color"
+)
+
+const (
+	width  = 440
+	height = 410
+)
+
+type anchor int
+
+const (
+	anchorStart anchor = iota
+	anchorMiddle
+)
+
+type rect struct {
+	x, y, w, h float64
+	fill       color.RGBA
+	stroke     *color.RGBA
+}
+
+type text struct {
+	x, y   float64
+	s      string
+	size   float64
+	fill   color.RGBA
+	bold   bool
+	anchor anchor
+}
+
+// diagram is a backend independent description of a board diagram, drawn by both the SVG and the raster renderer.
+type diagram struct {
+	width, height float64
+	background    color.RGBA
+	rects         []rect
+	texts         []text
+}
+
+var suitSymbols = map[pbn.Suit]string{
+	pbn.Spades:   "♠",
+	pbn.Hearts:   "♥",
+	pbn.Diamonds: "♦",
+	pbn.Clubs:    "♣",
+	pbn.NoTrump:  "NT",
+}
+
+var handOrigins = map[pbn.Direction][2]float64{
+	pbn.North: {170, 30},
+	pbn.West:  {20, 135},
+	pbn.East:  {290, 135},
+	pbn.South: {170, 235},
+}
+
+func layout(board pbn.Board, theme Theme) diagram {
+	d := diagram{width: width, height: height, background: theme.Background}
+	d.texts = append(d.texts,
+		text{x: 12, y: 26, s: fmt.Sprintf("Board %d", board.Number), size: 15, fill: theme.Foreground, bold: true},
+		text{x: 12, y: 46, s: "Dealer: " + board.Dealer.String(), size: 13, fill: theme.Foreground},
+		text{x: 12, y: 66, s: "Vul: " + board.Vulnerable.String(), size: 13, fill: theme.Foreground},
+	)
+	for _, direction := range []pbn.Direction{pbn.North, pbn.West, pbn.East, pbn.South} {
+		d.addHand(board.Hands[direction], handOrigins[direction], theme)
+	}
+	d.addCompass(board, theme)
+	if board.Ability != nil {
+		d.addDoubleDummy(board.Ability, theme)
+	}
+	if board.MinimaxScore.Level != 0 {
+		d.texts = append(d.texts,
+			text{x: 12, y: 338, s: "Par", size: 13, fill: theme.Foreground, bold: true},
+			text{x: 12, y: 358, s: fmt.Sprintf("%s %s", export.ContractString(board.MinimaxScore), board.MinimaxScore.Direction), size: 13, fill: theme.Foreground},
+			text{x: 12, y: 378, s: fmt.Sprintf("NS %+d", board.OptimumScore.Score), size: 13, fill: theme.Foreground},
+		)
+	}
+	return d
+}
+
+func (d *diagram) addHand(hand pbn.Hand, origin [2]float64, theme Theme) {
+	for i, suit := range []pbn.Suit{pbn.Spades, pbn.Hearts, pbn.Diamonds, pbn.Clubs} {
+		y := origin[1] + float64(i)*20
+		d.texts = append(d.texts,
+			text{x: origin[0], y: y, s: suitSymbols[suit], size: 15, fill: theme.SuitColours[suit]},
+			text{x: origin[0] + 18, y: y, s: export.DisplayCards(hand[suit]), size: 15, fill: theme.Foreground},
+		)
+	}
+}
+
+func (d *diagram) addCompass(board pbn.Board, theme Theme) {
+	const x, y, size, strip = 170.0, 110.0, 100.0, 18.0
+	d.rects = append(d.rects, rect{x: x, y: y, w: size, h: size, fill: theme.Table, stroke: &theme.Border})
+	nsVulnerable := board.Vulnerable == pbn.NorthSouth || board.Vulnerable == pbn.Both
+	ewVulnerable := board.Vulnerable == pbn.EastWest || board.Vulnerable == pbn.Both
+	if nsVulnerable {
+		d.rects = append(d.rects,
+			rect{x: x, y: y, w: size, h: strip, fill: theme.Vulnerable},
+			rect{x: x, y: y + size - strip, w: size, h: strip, fill: theme.Vulnerable},
+		)
+	}
+	if ewVulnerable {
+		d.rects = append(d.rects,
+			rect{x: x, y: y, w: strip, h: size, fill: theme.Vulnerable},
+			rect{x: x + size - strip, y: y, w: strip, h: size, fill: theme.Vulnerable},
+		)
+	}
+	white := rgb(0xffffff)
+	labels := map[pbn.Direction][2]float64{
+		pbn.North: {x + size/2, y + 14},
+		pbn.South: {x + size/2, y + size - 4},
+		pbn.West:  {x + strip/2, y + size/2 + 5},
+		pbn.East:  {x + size - strip/2, y + size/2 + 5},
+	}
+	for _, direction := range []pbn.Direction{pbn.North, pbn.East, pbn.South, pbn.West} {
+		position := labels[direction]
+		d.texts = append(d.texts, text{
+			x:      position[0],
+			y:      position[1],
+			s:      direction.String(),
+			size:   13,
+			fill:   white,
+			bold:   direction == board.Dealer,
+			anchor: anchorMiddle,
+		})
+	}
+}
+
+func (d *diagram) addDoubleDummy(ability pbn.Ability, theme Theme) {
+	const x, y, column, row = 290.0, 326.0, 26.0, 18.0
+	for i, suit := range []pbn.Suit{pbn.NoTrump, pbn.Spades, pbn.Hearts, pbn.Diamonds, pbn.Clubs} {
+		fill := theme.Foreground
+		if suit != pbn.NoTrump {
+			fill = theme.SuitColours[suit]
+		}
+		d.texts = append(d.texts, text{x: x + 30 + float64(i)*column, y: y, s: suitSymbols[suit], size: 12, fill: fill, anchor: anchorMiddle})
+	}
+	for j, direction := range []pbn.Direction{pbn.North, pbn.South, pbn.East, pbn.West} {
+		rowY := y + float64(j+1)*row
+		d.texts = append(d.texts, text{x: x, y: rowY, s: direction.String(), size: 12, fill: theme.Foreground, bold: true})
+		for i, suit := range []pbn.Suit{pbn.NoTrump, pbn.Spades, pbn.Hearts, pbn.Diamonds, pbn.Clubs} {
+			d.texts = append(d.texts, text{
+				x:      x + 30 + float64(i)*column,
+				y:      rowY,
+				s:      fmt.Sprintf("%d", ability[direction][suit]),
+				size:   12,
+				fill:   theme.Foreground,
+				anchor: anchorMiddle,
+			})
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package render
+
+import (
+	"github.com/fe-dox/go-pbn"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+	"image"
+	"image/draw"
+	"image/png"
+	"io"
+	"math"
+	"sync"
+)
+
+var (
+	fontsOnce    sync.Once
+	regularFont  *opentype.Font
+	boldFont     *opentype.Font
+	fontsLoadErr error
+)
+
+func loadFonts() error {
+	fontsOnce.Do(func() {
+		regularFont, fontsLoadErr = opentype.Parse(goregular.TTF)
+		if fontsLoadErr != nil {
+			return
+		}
+		boldFont, fontsLoadErr = opentype.Parse(gobold.TTF)
+	})
+	return fontsLoadErr
+}
+
+// Image rasterises the diagram. Scale multiplies the nominal 440x410 size, use 2 for high density displays.
+func Image(board pbn.Board, theme Theme, scale float64) (*image.RGBA, error) {
+	if scale <= 0 {
+		scale = 1
+	}
+	err := loadFonts()
+	if err != nil {
+		return nil, err
+	}
+	d := layout(board, theme)
+	img := image.NewRGBA(image.Rect(0, 0, int(math.Ceil(d.width*scale)), int(math.Ceil(d.height*scale))))
+	draw.Draw(img, img.Bounds(), image.NewUniform(d.background), image.Point{}, draw.Src)
+	for _, r := range d.rects {
+		bounds := image.Rect(px(r.x, scale), px(r.y, scale), px(r.x+r.w, scale), px(r.y+r.h, scale))
+		draw.Draw(img, bounds, image.NewUniform(r.fill), image.Point{}, draw.Over)
+		if r.stroke != nil {
+			stroke := image.NewUniform(*r.stroke)
+			draw.Draw(img, image.Rect(bounds.Min.X, bounds.Min.Y, bounds.Max.X, bounds.Min.Y+1), stroke, image.Point{}, draw.Over)
+			draw.Draw(img, image.Rect(bounds.Min.X, bounds.Max.Y-1, bounds.Max.X, bounds.Max.Y), stroke, image.Point{}, draw.Over)
+			draw.Draw(img, image.Rect(bounds.Min.X, bounds.Min.Y, bounds.Min.X+1, bounds.Max.Y), stroke, image.Point{}, draw.Over)
+			draw.Draw(img, image.Rect(bounds.Max.X-1, bounds.Min.Y, bounds.Max.X, bounds.Max.Y), stroke, image.Point{}, draw.Over)
+		}
+	}
+	faces := make(map[[2]float64]font.Face)
+	defer func() {
+		for _, face := range faces {
+			face.Close()
+		}
+	}()
+	for _, t := range d.texts {
+		bold := 0.0
+		if t.bold {
+			bold = 1
+		}
+		face, ok := faces[[2]float64{t.size, bold}]
+		if !ok {
+			f := regularFont
+			if t.bold {
+				f = boldFont
+			}
+			face, err = opentype.NewFace(f, &opentype.FaceOptions{Size: t.size * scale, DPI: 72, Hinting: font.HintingFull})
+			if err != nil {
+				return nil, err
+			}
+			faces[[2]float64{t.size, bold}] = face
+		}
+		drawer := font.Drawer{Dst: img, Src: image.NewUniform(t.fill), Face: face}
+		x := fixed.I(px(t.x, scale))
+		if t.anchor == anchorMiddle {
+			x -= drawer.MeasureString(t.s) / 2
+		}
+		drawer.Dot = fixed.Point26_6{X: x, Y: fixed.I(px(t.y, scale))}
+		drawer.DrawString(t.s)
+	}
+	return img, nil
+}
+
+func PNG(w io.Writer, board pbn.Board, theme Theme, scale float64) error {
+	img, err := Image(board, theme, scale)
+	if err != nil {
+		return err
+	}
+	return png.Encode(w, img)
+}
+
+func px(v float64, scale float64) int {
+	return int(math.Round(v * scale))
+}
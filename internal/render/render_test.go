@@ -0,0 +1,130 @@
+package render
+
+import (
+	"bytes"
+	"encoding/xml"
+	"github.com/fe-dox/go-pbn"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"strings"
+	"testing"
+)
+
+func testBoard() pbn.Board {
+	return pbn.Board{
+		Number:     7,
+		Dealer:     pbn.South,
+		Vulnerable: pbn.Both,
+		Hands: map[pbn.Direction]pbn.Hand{
+			pbn.North: {pbn.Spades: {pbn.A, pbn.K, 5}, pbn.Hearts: {pbn.Q, 7, 2}, pbn.Diamonds: {pbn.A, 9, 8, 4}, pbn.Clubs: {pbn.J, 6, 3}},
+			pbn.East:  {pbn.Spades: {pbn.Q, pbn.J, pbn.T}, pbn.Hearts: {pbn.A, pbn.K, 8}, pbn.Diamonds: {7, 6, 5}, pbn.Clubs: {pbn.K, 9, 8, 2}},
+			pbn.South: {pbn.Spades: {9, 8, 7, 6}, pbn.Hearts: {pbn.J, pbn.T, 9}, pbn.Diamonds: {pbn.K, pbn.Q}, pbn.Clubs: {pbn.A, pbn.Q, pbn.T, 4}},
+			pbn.West:  {pbn.Spades: {4, 3, 2}, pbn.Hearts: {6, 5, 4, 3}, pbn.Diamonds: {pbn.J, pbn.T, 3, 2}, pbn.Clubs: {7, 5}},
+		},
+	}
+}
+
+func TestSVG(t *testing.T) {
+	theme, err := ThemeByName("light")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	if err = SVG(&b, testBoard(), theme); err != nil {
+		t.Fatal(err)
+	}
+	dec := xml.NewDecoder(&b)
+	var texts []string
+	for {
+		token, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("SVG is not valid XML: %v", err)
+		}
+		if data, ok := token.(xml.CharData); ok && strings.TrimSpace(string(data)) != "" {
+			texts = append(texts, string(data))
+		}
+	}
+	for _, want := range []string{"Board 7", "Dealer: S", "Vul: All", "AQ104", "QJ10"} {
+		found := false
+		for _, text := range texts {
+			found = found || text == want
+		}
+		if !found {
+			t.Errorf("SVG does not contain %q", want)
+		}
+	}
+}
+
+func TestPNG(t *testing.T) {
+	tests := []struct {
+		name  string
+		theme string
+		scale float64
+		want  image.Rectangle
+	}{
+		{name: "light", theme: "light", scale: 1, want: image.Rect(0, 0, 440, 410)},
+		{name: "dark at double density", theme: "dark", scale: 2, want: image.Rect(0, 0, 880, 820)},
+		{name: "fractional scale", theme: "light", scale: 1.5, want: image.Rect(0, 0, 660, 615)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			theme, err := ThemeByName(tt.theme)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var b bytes.Buffer
+			if err = PNG(&b, testBoard(), theme, tt.scale); err != nil {
+				t.Fatal(err)
+			}
+			img, err := png.Decode(&b)
+			if err != nil {
+				t.Fatalf("PNG output does not decode: %v", err)
+			}
+			if img.Bounds() != tt.want {
+				t.Fatalf("PNG bounds = %v, want %v", img.Bounds(), tt.want)
+			}
+			// the corners are left to the background, the middle of the compass is the table
+			corners := []image.Point{{0, 0}, {tt.want.Max.X - 1, 0}, {0, tt.want.Max.Y - 1}, {tt.want.Max.X - 1, tt.want.Max.Y - 1}}
+			for _, corner := range corners {
+				if got := color.RGBAModel.Convert(img.At(corner.X, corner.Y)); got != theme.Background {
+					t.Errorf("PNG pixel at %v = %v, want background %v", corner, got, theme.Background)
+				}
+			}
+			centre := image.Pt(px(220, tt.scale), px(160, tt.scale))
+			if got := color.RGBAModel.Convert(img.At(centre.X, centre.Y)); got != theme.Table {
+				t.Errorf("PNG pixel at %v = %v, want table %v", centre, got, theme.Table)
+			}
+		})
+	}
+}
+
+func TestThemeWithSuitColours(t *testing.T) {
+	tests := []struct {
+		name    string
+		colours string
+		want    color.RGBA
+		wantErr bool
+	}{
+		{name: "four colours", colours: "#000000,#c62828,#ef6c00,#2e7d32", want: color.RGBA{R: 0xef, G: 0x6c, A: 0xff}},
+		{name: "too few colours", colours: "#000000,#c62828", wantErr: true},
+		{name: "invalid colour", colours: "#000000,#c62828,orange,#2e7d32", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			theme, _ := ThemeByName("light")
+			got, err := theme.WithSuitColours(tt.colours)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("WithSuitColours() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && got.SuitColours[pbn.Diamonds] != tt.want {
+				t.Errorf("WithSuitColours() diamonds = %v, want %v", got.SuitColours[pbn.Diamonds], tt.want)
+			}
+		})
+	}
+}
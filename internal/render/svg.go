@@ -0,0 +1,42 @@
+package render
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"github.com/fe-dox/go-pbn"
+	"io"
+)
+
+const fontFamily = "Helvetica, Arial, sans-serif"
+
+func SVG(w io.Writer, board pbn.Board, theme Theme) error {
+	d := layout(board, theme)
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" width="%g" height="%g" viewBox="0 0 %g %g">`+"\n", d.width, d.height, d.width, d.height)
+	fmt.Fprintf(bw, `<rect width="100%%" height="100%%" fill="%s"/>`+"\n", hex(d.background))
+	for _, r := range d.rects {
+		stroke := ""
+		if r.stroke != nil {
+			stroke = fmt.Sprintf(` stroke="%s"`, hex(*r.stroke))
+		}
+		fmt.Fprintf(bw, `<rect x="%g" y="%g" width="%g" height="%g" fill="%s"%s/>`+"\n", r.x, r.y, r.w, r.h, hex(r.fill), stroke)
+	}
+	for _, t := range d.texts {
+		attributes := fmt.Sprintf(`x="%g" y="%g" font-family="%s" font-size="%g" fill="%s"`, t.x, t.y, fontFamily, t.size, hex(t.fill))
+		if t.bold {
+			attributes += ` font-weight="bold"`
+		}
+		if t.anchor == anchorMiddle {
+			attributes += ` text-anchor="middle"`
+		}
+		fmt.Fprintf(bw, "<text %s>", attributes)
+		err := xml.EscapeText(bw, []byte(t.s))
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(bw, "</text>\n")
+	}
+	fmt.Fprint(bw, "</svg>\n")
+	return bw.Flush()
+}
@@ -0,0 +1,109 @@
+package render
+
+import (
+	"errors"
+	"fmt"
+	"github.com/fe-dox/go-pbn"
+	"image/color"
+	"strings"
+)
+
+var (
+	ErrUnknownTheme       = errors.New("unknown theme")
+	ErrInvalidColour      = errors.New("invalid colour, expected #rrggbb")
+	ErrInvalidSuitColours = errors.New("invalid suit colours, expected four comma separated colours for spades, hearts, diamonds and clubs")
+)
+
+type Theme struct {
+	Background  color.RGBA
+	Foreground  color.RGBA
+	Border      color.RGBA
+	Table       color.RGBA
+	Vulnerable  color.RGBA
+	SuitColours map[pbn.Suit]color.RGBA
+}
+
+var themes = map[string]Theme{
+	"light": {
+		Background: rgb(0xffffff),
+		Foreground: rgb(0x1a1a1a),
+		Border:     rgb(0x9a9a9a),
+		Table:      rgb(0x2e7d32),
+		Vulnerable: rgb(0xc62828),
+		SuitColours: map[pbn.Suit]color.RGBA{
+			pbn.Spades:   rgb(0x1a1a1a),
+			pbn.Hearts:   rgb(0xc62828),
+			pbn.Diamonds: rgb(0xc62828),
+			pbn.Clubs:    rgb(0x1a1a1a),
+		},
+	},
+	"dark": {
+		Background: rgb(0x1e1e1e),
+		Foreground: rgb(0xeeeeee),
+		Border:     rgb(0x5a5a5a),
+		Table:      rgb(0x1b5e20),
+		Vulnerable: rgb(0xef5350),
+		SuitColours: map[pbn.Suit]color.RGBA{
+			pbn.Spades:   rgb(0xeeeeee),
+			pbn.Hearts:   rgb(0xef5350),
+			pbn.Diamonds: rgb(0xef5350),
+			pbn.Clubs:    rgb(0xeeeeee),
+		},
+	},
+}
+
+func Themes() []string {
+	return []string{"light", "dark"}
+}
+
+func ThemeByName(name string) (Theme, error) {
+	theme, ok := themes[name]
+	if !ok {
+		return Theme{}, ErrUnknownTheme
+	}
+	suitColours := make(map[pbn.Suit]color.RGBA, len(theme.SuitColours))
+	for suit, c := range theme.SuitColours {
+		suitColours[suit] = c
+	}
+	theme.SuitColours = suitColours
+	return theme, nil
+}
+
+// WithSuitColours overrides suit colours with a "#rrggbb,#rrggbb,#rrggbb,#rrggbb" list in spades, hearts,
+// diamonds, clubs order, e.g. to get a four colour deck.
+func (t Theme) WithSuitColours(str string) (Theme, error) {
+	parts := strings.Split(str, ",")
+	if len(parts) != 4 {
+		return t, ErrInvalidSuitColours
+	}
+	suitColours := make(map[pbn.Suit]color.RGBA, 4)
+	for i, suit := range []pbn.Suit{pbn.Spades, pbn.Hearts, pbn.Diamonds, pbn.Clubs} {
+		c, err := ParseColour(strings.TrimSpace(parts[i]))
+		if err != nil {
+			return t, err
+		}
+		suitColours[suit] = c
+	}
+	t.SuitColours = suitColours
+	return t, nil
+}
+
+func ParseColour(str string) (color.RGBA, error) {
+	var r, g, b uint8
+	if len(str) != 7 || str[0] != '#' {
+		return color.RGBA{}, ErrInvalidColour
+	}
+	_, err := fmt.Sscanf(str, "#%02x%02x%02x", &r, &g, &b)
+	if err != nil {
+		return color.RGBA{}, ErrInvalidColour
+	}
+	return color.RGBA{R: r, G: g, B: b, A: 0xff}, nil
+}
+
+func hex(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+func rgb(v uint32) color.RGBA {
+	return color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 0xff}
+}
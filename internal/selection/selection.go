@@ -0,0 +1,362 @@
+package selection
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/fe-dox/go-pbn"
+	"github.com/fe-dox/tc-pbn-extractor/internal/extractor"
+)
+
+var (
+	ErrInvalidSelection     = errors.New("invalid board selection")
+	ErrBoardNotInTournament = errors.New("board does not exist in tournament")
+	ErrUnknownSession       = errors.New("unknown session")
+	ErrSessionAsPlayed      = errors.New("sessions can only be selected by TC number")
+	ErrEmptySelection       = errors.New("no boards selected")
+)
+
+const playedPrefix = "played:"
+
+// Error points at the token of the expression that could not be used.
+type Error struct {
+	Expression string
+	Token      string
+	Position   int
+	Err        error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("board selection %q, at %q (position %d): %v", e.Expression, e.Token, e.Position, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+type termKind int
+
+const (
+	termRange termKind = iota
+	termAll
+	termOdd
+	termEven
+	termSession
+)
+
+type term struct {
+	kind     termKind
+	exclude  bool
+	from, to int
+	session  string
+	token    string
+	position int
+}
+
+// Selection lists the TC boards to download. With AsPlayed set the expression was about numbers as played, which
+// are only known once a board is downloaded, so every board is downloaded and Keep filters them.
+type Selection struct {
+	AsPlayed bool
+	Boards   []int
+	terms    []term
+}
+
+// Parse resolves a selection expression against a tournament. An expression is a comma separated list of terms,
+// used in the given order:
+//
+//	7, 1-12, 12-1   boards and ranges, a descending range selects boards in descending order
+//	all, odd, even  every, every odd and every even board
+//	session:2       boards of the 2nd session, sessions can also be given by name
+//	!13, !odd       removes boards selected so far, an expression starting with an exclusion starts from all boards
+//
+// Prefixing the expression with "played:" makes numbers refer to numbers as played instead of TC numbers. An empty
+// expression selects all boards.
+func Parse(expr string, settings extractor.TournamentSettings) (Selection, error) {
+	var s Selection
+	body, offset, asPlayed := splitPrefix(expr)
+	s.AsPlayed = asPlayed
+	if body == "" {
+		body = "all"
+	}
+
+	var err error
+	s.terms, err = parseTerms(expr, body, offset)
+	if err != nil {
+		return Selection{}, err
+	}
+	if s.AsPlayed {
+		for _, t := range s.terms {
+			if t.kind == termSession {
+				return Selection{}, newError(expr, t, ErrSessionAsPlayed)
+			}
+		}
+		s.Boards = append([]int(nil), settings.BoardsNumbers...)
+		return s, nil
+	}
+
+	s.Boards, err = s.resolve(expr, settings)
+	if err != nil {
+		return Selection{}, err
+	}
+	if len(s.Boards) == 0 {
+		return Selection{}, ErrEmptySelection
+	}
+	return s, nil
+}
+
+// Validate checks the syntax of an expression, which is all that can be checked without the tournament.
+func Validate(expr string) error {
+	body, offset, _ := splitPrefix(expr)
+	if body == "" {
+		return nil
+	}
+	_, err := parseTerms(expr, body, offset)
+	return err
+}
+
+// splitPrefix strips the "played:" prefix, offset is where body starts in expr.
+func splitPrefix(expr string) (body string, offset int, asPlayed bool) {
+	body = strings.TrimSpace(expr)
+	offset = len(expr) - len(strings.TrimLeft(expr, " "))
+	if strings.HasPrefix(strings.ToLower(body), playedPrefix) {
+		asPlayed = true
+		body = body[len(playedPrefix):]
+		offset += len(playedPrefix)
+	}
+	if strings.TrimSpace(body) == "" {
+		body = ""
+	}
+	return body, offset, asPlayed
+}
+
+// Keep reports whether a downloaded board, identified by its number as played, belongs to the selection.
+func (s Selection) Keep(numberAsPlayed int) bool {
+	if !s.AsPlayed {
+		return true
+	}
+	keep := len(s.terms) > 0 && s.terms[0].exclude
+	for _, t := range s.terms {
+		if t.matches(numberAsPlayed) {
+			keep = !t.exclude
+		}
+	}
+	return keep
+}
+
+// Filter drops boards, downloaded from one TC board, that are not selected by their number as played.
+func (s Selection) Filter(boards []pbn.Board) []pbn.Board {
+	kept := boards[:0]
+	for _, board := range boards {
+		if s.Keep(board.Number) {
+			kept = append(kept, board)
+		}
+	}
+	return kept
+}
+
+func parseTerms(expr string, body string, offset int) ([]term, error) {
+	var terms []term
+	position := offset
+	for _, raw := range strings.Split(body, ",") {
+		token := strings.TrimSpace(raw)
+		t := term{token: token, position: position + len(raw) - len(strings.TrimLeft(raw, " ")) + 1}
+		position += len(raw) + 1
+		if token == "" {
+			return nil, newError(expr, t, ErrInvalidSelection)
+		}
+		value := token
+		if strings.HasPrefix(value, "!") {
+			t.exclude = true
+			value = strings.TrimSpace(value[1:])
+		}
+		lower := strings.ToLower(value)
+		switch {
+		case lower == "all":
+			t.kind = termAll
+		case lower == "odd":
+			t.kind = termOdd
+		case lower == "even":
+			t.kind = termEven
+		case strings.HasPrefix(lower, "session:"):
+			t.kind = termSession
+			t.session = strings.TrimSpace(value[len("session:"):])
+			if t.session == "" {
+				return nil, newError(expr, t, ErrInvalidSelection)
+			}
+		default:
+			var err error
+			t.kind = termRange
+			t.from, t.to, err = parseRange(value)
+			if err != nil {
+				return nil, newError(expr, t, ErrInvalidSelection)
+			}
+		}
+		terms = append(terms, t)
+	}
+	return terms, nil
+}
+
+func parseRange(value string) (int, int, error) {
+	from, to, isRange := strings.Cut(value, "-")
+	first, err := strconv.Atoi(strings.TrimSpace(from))
+	if err != nil || first < 1 {
+		return 0, 0, ErrInvalidSelection
+	}
+	if !isRange {
+		return first, first, nil
+	}
+	last, err := strconv.Atoi(strings.TrimSpace(to))
+	if err != nil || last < 1 {
+		return 0, 0, ErrInvalidSelection
+	}
+	return first, last, nil
+}
+
+func (s Selection) resolve(expr string, settings extractor.TournamentSettings) ([]int, error) {
+	exists := make(map[int]bool, len(settings.BoardsNumbers))
+	for _, number := range settings.BoardsNumbers {
+		exists[number] = true
+	}
+	var boards []int
+	selected := make(map[int]bool)
+	if s.terms[0].exclude {
+		boards = append(boards, settings.BoardsNumbers...)
+		for _, number := range boards {
+			selected[number] = true
+		}
+	}
+	for _, t := range s.terms {
+		numbers, err := t.numbers(settings)
+		if err != nil {
+			return nil, newError(expr, t, err)
+		}
+		if t.exclude {
+			excluded := make(map[int]bool, len(numbers))
+			for _, number := range numbers {
+				excluded[number] = true
+				delete(selected, number)
+			}
+			kept := boards[:0]
+			for _, number := range boards {
+				if !excluded[number] {
+					kept = append(kept, number)
+				}
+			}
+			boards = kept
+			continue
+		}
+		for _, number := range numbers {
+			if !exists[number] {
+				return nil, newError(expr, t, fmt.Errorf("%w: %d", ErrBoardNotInTournament, number))
+			}
+			if !selected[number] {
+				selected[number] = true
+				boards = append(boards, number)
+			}
+		}
+	}
+	return boards, nil
+}
+
+// numbers lists TC boards selected by the term, in the order they should be extracted.
+func (t term) numbers(settings extractor.TournamentSettings) ([]int, error) {
+	switch t.kind {
+	case termSession:
+		for _, session := range settings.Sessions {
+			if strconv.Itoa(session.Number) == t.session || strings.EqualFold(session.Name, t.session) {
+				return session.BoardsNumbers, nil
+			}
+		}
+		return nil, ErrUnknownSession
+	case termRange:
+		return t.rangeNumbers(settings.BoardsNumbers), nil
+	default:
+		numbers := make([]int, 0, len(settings.BoardsNumbers))
+		for _, number := range settings.BoardsNumbers {
+			if t.matches(number) {
+				numbers = append(numbers, number)
+			}
+		}
+		return numbers, nil
+	}
+}
+
+// rangeNumbers walks a range only as far as the boards of the tournament go, so a huge range costs no more than
+// the tournament. An included range ends with the first board beyond them, which resolve reports as missing,
+// an excluded one is cut to them.
+func (t term) rangeNumbers(boards []int) []int {
+	step := 1
+	if t.from > t.to {
+		step = -1
+	}
+	first, last := t.from, t.to
+	if len(boards) > 0 && t.exclude {
+		lowest, highest := boards[0], boards[0]
+		for _, number := range boards {
+			lowest = minInt(lowest, number)
+			highest = maxInt(highest, number)
+		}
+		if step == 1 {
+			first, last = maxInt(first, lowest), minInt(last, highest)
+		} else {
+			first, last = minInt(first, highest), maxInt(last, lowest)
+		}
+		if (last-first)*step < 0 {
+			return nil
+		}
+	}
+	var numbers []int
+	for number := first; ; number += step {
+		numbers = append(numbers, number)
+		if number == last || !t.exclude && !containsBoard(boards, number) {
+			return numbers
+		}
+	}
+}
+
+func containsBoard(boards []int, number int) bool {
+	for _, board := range boards {
+		if board == number {
+			return true
+		}
+	}
+	return false
+}
+
+func minInt(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a int, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func (t term) matches(number int) bool {
+	switch t.kind {
+	case termAll:
+		return true
+	case termOdd:
+		return number%2 == 1
+	case termEven:
+		return number%2 == 0
+	case termRange:
+		if t.from > t.to {
+			return number >= t.to && number <= t.from
+		}
+		return number >= t.from && number <= t.to
+	default:
+		return false
+	}
+}
+
+func newError(expr string, t term, err error) error {
+	return &Error{Expression: expr, Token: t.token, Position: t.position, Err: err}
+}
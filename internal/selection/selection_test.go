@@ -0,0 +1,112 @@
+package selection
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/fe-dox/tc-pbn-extractor/internal/extractor"
+)
+
+var settings = extractor.TournamentSettings{
+	StartBoardNumber: 1,
+	EndBoardNumber:   10,
+	BoardsNumbers:    []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10},
+	Sessions: []extractor.Session{
+		{Number: 1, Name: "Morning", BoardsNumbers: []int{1, 2, 3, 4, 5}},
+		{Number: 2, Name: "Afternoon", BoardsNumbers: []int{6, 7, 8, 9, 10}},
+	},
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		want    []int
+		wantErr error
+	}{
+		{name: "empty string", expr: "", want: []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}},
+		{name: "all", expr: "all", want: []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}},
+		{name: "single board", expr: "1", want: []int{1}},
+		{name: "single board out of range", expr: "11", wantErr: ErrBoardNotInTournament},
+		{name: "multiple boards", expr: "1,2,3", want: []int{1, 2, 3}},
+		{name: "multiple boards out of range", expr: "1,2,11", wantErr: ErrBoardNotInTournament},
+		{name: "multiple boards in any order", expr: "1,5,3,2", want: []int{1, 5, 3, 2}},
+		{name: "duplicates are selected once", expr: "1-3,2", want: []int{1, 2, 3}},
+		{name: "multiple boards with range", expr: "1,2,4-7", want: []int{1, 2, 4, 5, 6, 7}},
+		{name: "range out of range", expr: "1,2,4-11", wantErr: ErrBoardNotInTournament},
+		{name: "huge range", expr: "1-9999999999", wantErr: ErrBoardNotInTournament},
+		{name: "huge descending range", expr: "9999999999-1", wantErr: ErrBoardNotInTournament},
+		{name: "huge exclusion", expr: "1-10,!5-9999999999", want: []int{1, 2, 3, 4}},
+		{name: "exclusion beyond the boards", expr: "1-10,!11-9999999999", want: []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}},
+		{name: "descending range", expr: "1,2,7-4", want: []int{1, 2, 7, 6, 5, 4}},
+		{name: "exclusion", expr: "1-10,!3,!5-9", want: []int{1, 2, 4, 10}},
+		{name: "leading exclusion starts from all", expr: "!2-9", want: []int{1, 10}},
+		{name: "odd and even", expr: "odd,!1, even", want: []int{3, 5, 7, 9, 2, 4, 6, 8, 10}},
+		{name: "session by number", expr: "session:2,!7", want: []int{6, 8, 9, 10}},
+		{name: "session by name", expr: "session:morning", want: []int{1, 2, 3, 4, 5}},
+		{name: "unknown session", expr: "session:3", wantErr: ErrUnknownSession},
+		{name: "excluding everything", expr: "1,!1", wantErr: ErrEmptySelection},
+		{name: "invalid token", expr: "1,x", wantErr: ErrInvalidSelection},
+		{name: "empty token", expr: "1,,2", wantErr: ErrInvalidSelection},
+		{name: "played numbers download every board", expr: "played:1-3", want: []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}},
+		{name: "session by played number", expr: "played:session:1", wantErr: ErrSessionAsPlayed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.expr, settings)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got.Boards, tt.want) {
+				t.Errorf("Parse() got = %v, want %v", got.Boards, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrorPosition(t *testing.T) {
+	_, err := Parse("1-4, !x", settings)
+	var selectionErr *Error
+	if !errors.As(err, &selectionErr) {
+		t.Fatalf("Parse() error = %v, want *Error", err)
+	}
+	if selectionErr.Token != "!x" || selectionErr.Position != 6 {
+		t.Errorf("Parse() error token = %q at %d, want \"!x\" at 6", selectionErr.Token, selectionErr.Position)
+	}
+}
+
+func TestSelection_Keep(t *testing.T) {
+	s, err := Parse("played:odd,!5-9", settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var kept []int
+	for number := 1; number <= 12; number++ {
+		if s.Keep(number) {
+			kept = append(kept, number)
+		}
+	}
+	if want := []int{1, 3, 11}; !reflect.DeepEqual(kept, want) {
+		t.Errorf("Keep() kept = %v, want %v", kept, want)
+	}
+}
+
+func TestParseErrorBoard(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{expr: "4-9999999999", want: "board does not exist in tournament: 11"},
+		{expr: "9999999999-4", want: "board does not exist in tournament: 9999999999"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := Parse(tt.expr, settings)
+			var selectionErr *Error
+			if !errors.As(err, &selectionErr) || selectionErr.Err.Error() != tt.want {
+				t.Errorf("Parse() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
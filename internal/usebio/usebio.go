@@ -0,0 +1,217 @@
+package usebio
+
+import (
+	"encoding/xml"
+	"fmt"
+	"github.com/fe-dox/go-pbn"
+	"github.com/fe-dox/tc-pbn-extractor/internal/extractor"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const Version = "1.2"
+
+type Usebio struct {
+	XMLName xml.Name `xml:"USEBIO"`
+	Version string   `xml:"Version,attr"`
+	Event   Event    `xml:"EVENT"`
+}
+
+type Event struct {
+	EventType        string  `xml:"EVENT_TYPE,attr"`
+	EventDescription string  `xml:"EVENT_DESCRIPTION"`
+	ProgramName      string  `xml:"PROGRAM_NAME"`
+	WinnerType       int     `xml:"WINNER_TYPE"`
+	Participants     []Pair  `xml:"PARTICIPANTS>PAIR"`
+	Boards           []Board `xml:"BOARD"`
+}
+
+type Pair struct {
+	PairNumber int      `xml:"PAIR_NUMBER"`
+	Direction  string   `xml:"DIRECTION,omitempty"`
+	Place      string   `xml:"PLACE"`
+	TotalScore string   `xml:"TOTAL_SCORE"`
+	Players    []Player `xml:"PLAYER"`
+}
+
+type Player struct {
+	PlayerName string `xml:"PLAYER_NAME"`
+}
+
+type Board struct {
+	BoardNumber    int             `xml:"BOARD_NUMBER"`
+	TravellerLines []TravellerLine `xml:"TRAVELLER_LINE"`
+}
+
+type TravellerLine struct {
+	NsPairNumber  int    `xml:"NS_PAIR_NUMBER"`
+	EwPairNumber  int    `xml:"EW_PAIR_NUMBER"`
+	Contract      string `xml:"CONTRACT"`
+	PlayedBy      string `xml:"PLAYED_BY,omitempty"`
+	Lead          string `xml:"LEAD,omitempty"`
+	Tricks        int    `xml:"TRICKS"`
+	Score         int    `xml:"SCORE"`
+	NsMatchPoints string `xml:"NS_MATCH_POINTS"`
+	EwMatchPoints string `xml:"EW_MATCH_POINTS"`
+}
+
+// pairKey identifies a pair across travellers. In a Mitchell movement NS and EW pairs share numbers, so pairs
+// with the same number are only merged when their players are the same. Without player names the direction tells
+// them apart, a Howell pair changing direction is then counted as two pairs.
+type pairKey struct {
+	number    int
+	direction string
+	players   string
+}
+
+type pairTotals struct {
+	pair    extractor.RawPair
+	total   float64
+	sitsNS  bool
+	sitsEW  bool
+	ordinal int
+}
+
+// Build creates a USEBIO document out of TC protocols, keyed by board number as numbered in TC. Places are
+// derived from the sum of match points, separately for NS and EW when no pair changed direction.
+func Build(eventName string, generator string, protocols map[int]extractor.RawProtocol) Usebio {
+	numbers := make([]int, 0, len(protocols))
+	for number := range protocols {
+		numbers = append(numbers, number)
+	}
+	sort.Ints(numbers)
+
+	pairs := make(map[pairKey]*pairTotals)
+	addPair := func(pair extractor.RawPair, points float64, ns bool) {
+		key := pairKey{number: pair.Number, players: strings.Join(pair.Players, ",")}
+		if len(pair.Players) == 0 {
+			key.direction = "EW"
+			if ns {
+				key.direction = "NS"
+			}
+		}
+		totals, ok := pairs[key]
+		if !ok {
+			totals = &pairTotals{pair: pair, ordinal: len(pairs)}
+			pairs[key] = totals
+		}
+		totals.total += points
+		totals.sitsNS = totals.sitsNS || ns
+		totals.sitsEW = totals.sitsEW || !ns
+	}
+
+	event := Event{
+		EventType:        "MP_PAIRS",
+		EventDescription: eventName,
+		ProgramName:      generator,
+	}
+	for _, number := range numbers {
+		for _, group := range protocols[number].ScoringGroups {
+			if len(group.Results) == 0 {
+				continue
+			}
+			board := Board{BoardNumber: group.Distribution.NumberAsPlayed}
+			if board.BoardNumber == 0 {
+				board.BoardNumber = number
+			}
+			for _, result := range group.Results {
+				addPair(result.PairNS, result.ResultNS, true)
+				addPair(result.PairEW, result.ResultEW, false)
+				line := TravellerLine{
+					NsPairNumber:  result.PairNS.Number,
+					EwPairNumber:  result.PairEW.Number,
+					Contract:      result.Contract,
+					Lead:          result.Lead,
+					Tricks:        result.Tricks,
+					Score:         result.ScoreNS,
+					NsMatchPoints: formatPoints(result.ResultNS),
+					EwMatchPoints: formatPoints(result.ResultEW),
+				}
+				if result.Contract != "" && !strings.EqualFold(result.Contract, "pass") {
+					line.PlayedBy = pbn.Direction(result.Declarer % 4).String()
+				}
+				board.TravellerLines = append(board.TravellerLines, line)
+			}
+			event.Boards = append(event.Boards, board)
+		}
+	}
+
+	mitchell := true
+	ranked := make([]*pairTotals, 0, len(pairs))
+	for _, totals := range pairs {
+		mitchell = mitchell && totals.sitsNS != totals.sitsEW
+		ranked = append(ranked, totals)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].total != ranked[j].total {
+			return ranked[i].total > ranked[j].total
+		}
+		return ranked[i].ordinal < ranked[j].ordinal
+	})
+	event.WinnerType = 1
+	if mitchell && len(ranked) > 0 {
+		event.WinnerType = 2
+	}
+	event.Participants = rank(ranked, mitchell)
+	return Usebio{Version: Version, Event: event}
+}
+
+func rank(ranked []*pairTotals, mitchell bool) []Pair {
+	participants := make([]Pair, 0, len(ranked))
+	for _, field := range []string{"NS", "EW"} {
+		var fieldPairs []*pairTotals
+		for _, totals := range ranked {
+			if !mitchell || (field == "NS") == totals.sitsNS {
+				fieldPairs = append(fieldPairs, totals)
+			}
+		}
+		for i, totals := range fieldPairs {
+			place := i + 1
+			for place > 1 && fieldPairs[place-2].total == totals.total {
+				place--
+			}
+			tied := (i > 0 && fieldPairs[i-1].total == totals.total) ||
+				(i+1 < len(fieldPairs) && fieldPairs[i+1].total == totals.total)
+			pair := Pair{
+				PairNumber: totals.pair.Number,
+				Place:      strconv.Itoa(place),
+				TotalScore: formatPoints(totals.total),
+			}
+			if tied {
+				pair.Place += "="
+			}
+			if mitchell {
+				pair.Direction = field
+			}
+			for _, player := range totals.pair.Players {
+				pair.Players = append(pair.Players, Player{PlayerName: player})
+			}
+			participants = append(participants, pair)
+		}
+		if !mitchell {
+			break
+		}
+	}
+	return participants
+}
+
+func formatPoints(points float64) string {
+	return strconv.FormatFloat(points, 'f', -1, 64)
+}
+
+func Write(w io.Writer, document Usebio) error {
+	_, err := io.WriteString(w, xml.Header)
+	if err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	err = enc.Encode(document)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w)
+	return err
+}
@@ -0,0 +1,116 @@
+package usebio
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/fe-dox/tc-pbn-extractor/internal/extractor"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func protocol(results ...extractor.RawResult) extractor.RawProtocol {
+	return extractor.RawProtocol{ScoringGroups: []extractor.RawScoringGroup{{Results: results}}}
+}
+
+func pair(number int, players ...string) extractor.RawPair {
+	return extractor.RawPair{Number: number, Players: players}
+}
+
+func TestBuildPlaces(t *testing.T) {
+	tests := []struct {
+		name       string
+		protocols  map[int]extractor.RawProtocol
+		winnerType int
+		places     []string
+		directions []string
+	}{
+		{
+			name: "mitchell",
+			protocols: map[int]extractor.RawProtocol{
+				1: protocol(
+					extractor.RawResult{PairNS: pair(1, "A", "B"), PairEW: pair(1, "C", "D"), ResultNS: 2, ResultEW: 0},
+					extractor.RawResult{PairNS: pair(2, "E", "F"), PairEW: pair(2, "G", "H"), ResultNS: 0, ResultEW: 2},
+				),
+			},
+			winnerType: 2,
+			places:     []string{"1", "2", "1", "2"},
+			directions: []string{"NS", "NS", "EW", "EW"},
+		},
+		{
+			name: "mitchell without names",
+			protocols: map[int]extractor.RawProtocol{
+				1: protocol(
+					extractor.RawResult{PairNS: pair(1), PairEW: pair(1), ResultNS: 2, ResultEW: 0},
+					extractor.RawResult{PairNS: pair(2), PairEW: pair(2), ResultNS: 0, ResultEW: 2},
+				),
+			},
+			winnerType: 2,
+			places:     []string{"1", "2", "1", "2"},
+			directions: []string{"NS", "NS", "EW", "EW"},
+		},
+		{
+			name: "howell with tie",
+			protocols: map[int]extractor.RawProtocol{
+				1: protocol(
+					extractor.RawResult{PairNS: pair(1, "A", "B"), PairEW: pair(2, "C", "D"), ResultNS: 1, ResultEW: 1},
+				),
+				2: protocol(
+					extractor.RawResult{PairNS: pair(2, "C", "D"), PairEW: pair(1, "A", "B"), ResultNS: 1, ResultEW: 1},
+					extractor.RawResult{PairNS: pair(3, "E", "F"), PairEW: pair(4, "G", "H"), ResultNS: 0, ResultEW: 2},
+				),
+			},
+			winnerType: 1,
+			places:     []string{"1=", "1=", "1=", "4"},
+			directions: []string{"", "", "", ""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Build("Event", "generator", tt.protocols)
+			if got.Event.WinnerType != tt.winnerType {
+				t.Errorf("Build() winner type = %v, want %v", got.Event.WinnerType, tt.winnerType)
+			}
+			var places, directions []string
+			for _, p := range got.Event.Participants {
+				places = append(places, p.Place)
+				directions = append(directions, p.Direction)
+			}
+			if !reflect.DeepEqual(places, tt.places) {
+				t.Errorf("Build() places = %v, want %v", places, tt.places)
+			}
+			if !reflect.DeepEqual(directions, tt.directions) {
+				t.Errorf("Build() directions = %v, want %v", directions, tt.directions)
+			}
+		})
+	}
+}
+
+// TestBuild_Protocols builds USEBIO out of TC protocol files, testdata/usebio.xml is the document expected.
+func TestBuild_Protocols(t *testing.T) {
+	protocols := make(map[int]extractor.RawProtocol)
+	for _, number := range []int{1, 2} {
+		raw, err := os.ReadFile(filepath.Join("testdata", fmt.Sprintf("p%d.json", number)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var protocol extractor.RawProtocol
+		if err = json.Unmarshal(raw, &protocol); err != nil {
+			t.Fatal(err)
+		}
+		protocols[number] = protocol
+	}
+	want, err := os.ReadFile(filepath.Join("testdata", "usebio.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	if err = Write(&b, Build("Test Pairs", "tcpbn", protocols)); err != nil {
+		t.Fatal(err)
+	}
+	if got := b.String(); got != string(want) {
+		t.Errorf("Write() =\n%s\nwant\n%s", got, want)
+	}
+}